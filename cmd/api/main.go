@@ -8,8 +8,12 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
+	// Часовые пояса пользователей не зависят от tzdata в образе
+	_ "time/tzdata"
 
 	"github.com/gorilla/mux"
 	"github.com/zazaza5818/pr-reviewer-service/internal/auth"
@@ -17,6 +21,7 @@ import (
 	"github.com/zazaza5818/pr-reviewer-service/internal/database"
 	"github.com/zazaza5818/pr-reviewer-service/internal/handlers"
 	"github.com/zazaza5818/pr-reviewer-service/internal/middleware"
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
 	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
 	"github.com/zazaza5818/pr-reviewer-service/internal/service"
 )
@@ -61,16 +66,120 @@ func main() {
 	teamRepo := repository.NewTeamRepository(db.DB)
 	userRepo := repository.NewUserRepository(db.DB)
 	prRepo := repository.NewPullRequestRepository(db.DB)
+	eventRepo := repository.NewAssignmentEventRepository(db.DB)
+	vcsRepo := repository.NewVCSRepository(db.DB)
+	webhookRepo := repository.NewWebhookRepository(db.DB)
+	outboxRepo := repository.NewOutboxRepository(db.DB)
+	availabilityRepo := repository.NewAvailabilityRepository(db.DB)
+	ownershipRepo := repository.NewOwnershipRepository(db.DB)
+	ruleRepo := repository.NewRuleRepository(db.DB)
+	transactor := repository.NewTransactor(db.DB)
+
+	// Политики команд проверяются при загрузке. Некорректная политика
+	// пропускается с ошибкой в логе, и команда работает с настройками из БД
+	loaded, skipped, err := service.LoadPolicies(cfg.Policy.Dir)
+	if err != nil {
+		log.Fatalf("Failed to load team policies: %v", err)
+	}
+	for _, err := range skipped {
+		log.Printf("Skipped team policy: %v", err)
+	}
+	policyService := service.NewPolicyService(teamRepo, userRepo)
+	checked := make([]*models.TeamPolicy, 0, len(loaded.Policies()))
+	for _, policy := range loaded.Policies() {
+		validation, err := policyService.Check(context.Background(), policy)
+		if err != nil {
+			log.Fatalf("Failed to check policy of team %s: %v", policy.Team, err)
+		}
+		if !validation.Valid {
+			log.Printf("Skipped invalid policy of team %s: %s", policy.Team, strings.Join(validation.Problems, "; "))
+			continue
+		}
+		checked = append(checked, policy)
+	}
+	policies, err := service.NewPolicySet(checked)
+	if err != nil {
+		log.Fatalf("Failed to load team policies: %v", err)
+	}
+	log.Printf("Loaded %d team policies", len(policies.Policies()))
+
+	// Стратегии выбора ревьюверов
+	selectors, err := service.NewSelectorRegistry(service.SelectorConfig{
+		DefaultStrategy:     cfg.Reviewer.Strategy,
+		TeamStrategies:      policies.Strategies(cfg.Reviewer.TeamStrategies),
+		Weights:             cfg.Reviewer.Weights,
+		StrictCapacityTeams: cfg.Reviewer.StrictCapacityTeams,
+	}, prRepo)
+	if err != nil {
+		log.Fatalf("Failed to configure reviewer selection: %v", err)
+	}
+
+	// Исходящие вебхуки доставляются в фоне до остановки сервера. Незавершенные
+	// доставки хранятся в БД и продолжаются после перезапуска
+	dispatcher := service.NewWebhookDispatcher(webhookRepo, transactor, service.WebhookDispatcherConfig{
+		MaxAttempts:    cfg.Webhook.MaxAttempts,
+		InitialBackoff: cfg.Webhook.InitialBackoff,
+		MaxBackoff:     cfg.Webhook.MaxBackoff,
+		Timeout:        cfg.Webhook.Timeout,
+		Workers:        cfg.Webhook.Workers,
+		PollInterval:   cfg.Webhook.PollInterval,
+	})
+
+	// События пишутся в outbox в транзакции изменения и публикуются
+	// в приемники фоновым диспетчером
+	sinks := []service.EventSink{dispatcher}
+	if cfg.Outbox.LogEvents {
+		sinks = append(sinks, service.LogSink{})
+	}
+	outboxDispatcher := service.NewOutboxDispatcher(outboxRepo, transactor, sinks, service.OutboxDispatcherConfig{
+		PollInterval: cfg.Outbox.PollInterval,
+		BatchSize:    cfg.Outbox.BatchSize,
+		MaxAttempts:  cfg.Outbox.MaxAttempts,
+	})
+	events := service.NewOutboxPublisher(outboxRepo)
+
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	var dispatchWG sync.WaitGroup
+	dispatchWG.Add(2)
+	go func() {
+		defer dispatchWG.Done()
+		dispatcher.Run(dispatchCtx)
+	}()
+	go func() {
+		defer dispatchWG.Done()
+		outboxDispatcher.Run(dispatchCtx)
+	}()
 
 	// Инициализируем сервисы
-	teamService := service.NewTeamService(teamRepo, userRepo)
-	userService := service.NewUserService(userRepo, prRepo)
-	prService := service.NewPullRequestService(userRepo, prRepo)
+	prService := service.NewPullRequestService(userRepo, prRepo, teamRepo, eventRepo, ownershipRepo, ruleRepo, transactor, selectors, policies, events, service.SystemClock)
+	userService := service.NewUserService(userRepo, prRepo, availabilityRepo, transactor, prService, events, service.SystemClock)
+	teamService := service.NewTeamService(teamRepo, userRepo, transactor, prService, selectors, policies, events, service.SystemClock)
+	webhookService := service.NewWebhookService(webhookRepo)
+	ownershipService := service.NewOwnershipService(ownershipRepo, userRepo, teamRepo)
+	ruleService := service.NewRuleService(ruleRepo, userRepo)
+	vcsService := service.NewVCSService(vcsRepo, userRepo, prRepo, transactor, prService)
+
+	// Открытые ревью отсутствующих переназначаются в фоне, когда начинается период
+	absenceScheduler := service.NewAbsenceScheduler(availabilityRepo, transactor, prService, service.AbsenceSchedulerConfig{
+		PollInterval: cfg.Absence.PollInterval,
+		BatchSize:    cfg.Absence.BatchSize,
+		RetryDelay:   cfg.Absence.RetryDelay,
+	})
+	dispatchWG.Add(1)
+	go func() {
+		defer dispatchWG.Done()
+		absenceScheduler.Run(dispatchCtx)
+	}()
 
 	// Инициализируем обработчики
 	teamHandler := handlers.NewTeamHandler(teamService)
 	userHandler := handlers.NewUserHandler(userService)
 	prHandler := handlers.NewPRHandler(prService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	ownershipHandler := handlers.NewOwnershipHandler(ownershipService)
+	ruleHandler := handlers.NewRuleHandler(ruleService)
+	policyHandler := handlers.NewPolicyHandler(policyService)
+	vcsHandler := handlers.NewVCSHandler(vcsService, cfg.VCS.GitHubWebhookSecret, cfg.VCS.GitLabWebhookToken)
 	healthHandler := handlers.NewHealthHandler()
 
 	// Настраиваем роутер
@@ -82,10 +191,27 @@ func main() {
 	// Team routes (требуют аутентификацию)
 	router.Handle("/team/add", middleware.RequireAuth(http.HandlerFunc(teamHandler.CreateTeam))).Methods("POST")
 	router.Handle("/team/get", middleware.RequireAuth(http.HandlerFunc(teamHandler.GetTeam))).Methods("GET")
+	// настройки команды меняет только admin
+	router.Handle("/team/settings", middleware.RequireAuth(http.HandlerFunc(teamHandler.GetSettings))).Methods("GET")
+	router.Handle("/team/settings", middleware.RequireAdmin(http.HandlerFunc(teamHandler.UpdateSettings))).Methods("POST")
+	router.Handle("/team/fallbacks", middleware.RequireAuth(http.HandlerFunc(teamHandler.GetFallbacks))).Methods("GET")
+	router.Handle("/team/fallbacks", middleware.RequireAdmin(http.HandlerFunc(teamHandler.SetFallbacks))).Methods("POST")
+	// состав команды меняет только admin
+	router.Handle("/team/addMember", middleware.RequireAdmin(http.HandlerFunc(teamHandler.AddMember))).Methods("POST")
+	router.Handle("/team/removeMember", middleware.RequireAdmin(http.HandlerFunc(teamHandler.RemoveMember))).Methods("POST")
+	router.Handle("/team/moveMember", middleware.RequireAdmin(http.HandlerFunc(teamHandler.MoveMember))).Methods("POST")
+	router.Handle("/team/rename", middleware.RequireAdmin(http.HandlerFunc(teamHandler.RenameTeam))).Methods("POST")
+	router.Handle("/team/deactivate", middleware.RequireAdmin(http.HandlerFunc(teamHandler.DeactivateTeam))).Methods("POST")
+	router.Handle("/team/deactivateMembers", middleware.RequireAdmin(http.HandlerFunc(teamHandler.DeactivateMembers))).Methods("POST")
 
 	// User routes
 	// setIsActive требует admin токен
 	router.Handle("/users/setIsActive", middleware.RequireAdmin(http.HandlerFunc(userHandler.SetIsActive))).Methods("POST")
+	router.Handle("/users/setAvailability", middleware.RequireAdmin(http.HandlerFunc(userHandler.SetAvailability))).Methods("POST")
+	router.Handle("/users/setWorkingHours", middleware.RequireAdmin(http.HandlerFunc(userHandler.SetWorkingHours))).Methods("POST")
+	router.Handle("/users/setSeniority", middleware.RequireAdmin(http.HandlerFunc(userHandler.SetSeniority))).Methods("POST")
+	router.Handle("/users/setSkills", middleware.RequireAdmin(http.HandlerFunc(userHandler.SetSkills))).Methods("POST")
+	router.Handle("/users/getAvailability", middleware.RequireAuth(http.HandlerFunc(userHandler.GetAvailability))).Methods("GET")
 	// getReview требует обычную аутентификацию
 	router.Handle("/users/getReview", middleware.RequireAuth(http.HandlerFunc(userHandler.GetReviews))).Methods("GET")
 
@@ -93,6 +219,40 @@ func main() {
 	router.Handle("/pullRequest/create", middleware.RequireAdmin(http.HandlerFunc(prHandler.CreatePR))).Methods("POST")
 	router.Handle("/pullRequest/merge", middleware.RequireAdmin(http.HandlerFunc(prHandler.MergePR))).Methods("POST")
 	router.Handle("/pullRequest/reassign", middleware.RequireAdmin(http.HandlerFunc(prHandler.ReassignPR))).Methods("POST")
+	// решение по ревью отправляет сам ревьювер своим токеном
+	router.Handle("/pullRequest/review", middleware.RequireAuth(http.HandlerFunc(prHandler.SubmitReview))).Methods("POST")
+	router.Handle("/pullRequest/history", middleware.RequireAuth(http.HandlerFunc(prHandler.GetHistory))).Methods("GET")
+	router.Handle("/pullRequest/close", middleware.RequireAdmin(http.HandlerFunc(prHandler.ClosePR))).Methods("POST")
+	router.Handle("/pullRequest/reopen", middleware.RequireAdmin(http.HandlerFunc(prHandler.ReopenPR))).Methods("POST")
+	router.Handle("/pullRequest/markReady", middleware.RequireAdmin(http.HandlerFunc(prHandler.MarkReady))).Methods("POST")
+	router.Handle("/pullRequest/setTags", middleware.RequireAdmin(http.HandlerFunc(prHandler.SetTags))).Methods("POST")
+
+	// VCS routes
+	// вебхуки аутентифицируются подписью провайдера, а не JWT
+	router.HandleFunc("/webhooks/github", vcsHandler.GitHubWebhook).Methods("POST")
+	router.HandleFunc("/webhooks/gitlab", vcsHandler.GitLabWebhook).Methods("POST")
+	router.Handle("/vcs/identities", middleware.RequireAdmin(http.HandlerFunc(vcsHandler.LinkIdentity))).Methods("POST")
+
+	// Outgoing webhook routes (требуют admin токен)
+	router.Handle("/webhooks/subscriptions", middleware.RequireAdmin(http.HandlerFunc(webhookHandler.CreateSubscription))).Methods("POST")
+	router.Handle("/webhooks/subscriptions", middleware.RequireAdmin(http.HandlerFunc(webhookHandler.ListSubscriptions))).Methods("GET")
+	router.Handle("/webhooks/subscriptions/delete", middleware.RequireAdmin(http.HandlerFunc(webhookHandler.DeleteSubscription))).Methods("POST")
+	router.Handle("/webhooks/deliveries", middleware.RequireAdmin(http.HandlerFunc(webhookHandler.GetDeliveries))).Methods("GET")
+
+	// Code ownership routes (изменение требует admin токен)
+	router.Handle("/ownership/rules", middleware.RequireAuth(http.HandlerFunc(ownershipHandler.ListRules))).Methods("GET")
+	router.Handle("/ownership/rules", middleware.RequireAdmin(http.HandlerFunc(ownershipHandler.CreateRule))).Methods("POST")
+	router.Handle("/ownership/rules/update", middleware.RequireAdmin(http.HandlerFunc(ownershipHandler.UpdateRule))).Methods("POST")
+	router.Handle("/ownership/rules/delete", middleware.RequireAdmin(http.HandlerFunc(ownershipHandler.DeleteRule))).Methods("POST")
+
+	// Reviewer rule routes (изменение требует admin токен)
+	router.Handle("/rules", middleware.RequireAuth(http.HandlerFunc(ruleHandler.ListRules))).Methods("GET")
+	router.Handle("/rules", middleware.RequireAdmin(http.HandlerFunc(ruleHandler.CreateRule))).Methods("POST")
+	router.Handle("/rules/update", middleware.RequireAdmin(http.HandlerFunc(ruleHandler.UpdateRule))).Methods("POST")
+	router.Handle("/rules/delete", middleware.RequireAdmin(http.HandlerFunc(ruleHandler.DeleteRule))).Methods("POST")
+
+	// Policy routes: проверка ничего не меняет, поэтому доступна любому пользователю
+	router.Handle("/policy/validate", middleware.RequireAuth(http.HandlerFunc(policyHandler.Validate))).Methods("POST")
 
 	// Middleware для логирования
 	router.Use(middleware.Logging)
@@ -127,5 +287,8 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	stopDispatch()
+	dispatchWG.Wait()
+
 	log.Println("Server exited")
 }
@@ -4,15 +4,24 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config содержит конфигурацию приложения
 type Config struct {
-	DB     DatabaseConfig
-	Server ServerConfig
-	Env    string
+	DB       DatabaseConfig
+	Server   ServerConfig
+	Reviewer ReviewerConfig
+	VCS      VCSConfig
+	Webhook  WebhookConfig
+	Outbox   OutboxConfig
+	Absence  AbsenceConfig
+	Policy   PolicyConfig
+	Env      string
 }
 
 // DatabaseConfig содержит параметры подключения к БД
@@ -31,6 +40,63 @@ type ServerConfig struct {
 	Host string
 }
 
+// ReviewerConfig содержит параметры назначения ревьюверов
+type ReviewerConfig struct {
+	// Strategy - стратегия выбора по умолчанию для всего развертывания
+	Strategy string
+	// TeamStrategies переопределяет стратегию для отдельных команд
+	TeamStrategies map[string]string
+	// Weights - веса пользователей для стратегии weighted
+	Weights map[string]int
+	// StrictCapacityTeams - команды, которым при исчерпании лимитов
+	// возвращается ошибка вместо назначения меньшего числа ревьюверов
+	StrictCapacityTeams []string
+}
+
+// VCSConfig содержит параметры интеграции с системами контроля версий
+type VCSConfig struct {
+	// GitHubWebhookSecret - секрет для проверки подписи вебхуков GitHub,
+	// пустое значение отключает прием вебхуков
+	GitHubWebhookSecret string
+	// GitLabWebhookToken - токен, который GitLab передает в X-Gitlab-Token,
+	// пустое значение отключает прием вебхуков
+	GitLabWebhookToken string
+}
+
+// WebhookConfig содержит параметры доставки исходящих вебхуков
+type WebhookConfig struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Timeout        time.Duration
+	Workers        int
+	PollInterval   time.Duration
+}
+
+// OutboxConfig содержит параметры публикации событий из outbox
+type OutboxConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	// LogEvents дублирует опубликованные события в лог сервиса
+	LogEvents bool
+}
+
+// AbsenceConfig содержит параметры обработки периодов отсутствия
+type AbsenceConfig struct {
+	// PollInterval - пауза между проверками начавшихся отсутствий
+	PollInterval time.Duration
+	BatchSize    int
+	// RetryDelay - пауза перед повтором периода после ошибки переназначения
+	RetryDelay time.Duration
+}
+
+// PolicyConfig содержит параметры политик назначения ревьюверов
+type PolicyConfig struct {
+	// Dir - каталог с JSON-политиками команд, пустое значение отключает политики
+	Dir string
+}
+
 // Load загружает конфигурацию из переменных окружения
 func Load() (*Config, error) {
 	_ = godotenv.Load()
@@ -48,9 +114,118 @@ func Load() (*Config, error) {
 			Port: getEnv("SERVER_PORT", "8080"),
 			Host: getEnv("SERVER_HOST", "0.0.0.0"),
 		},
+		VCS: VCSConfig{
+			GitHubWebhookSecret: getEnv("GITHUB_WEBHOOK_SECRET", ""),
+			GitLabWebhookToken:  getEnv("GITLAB_WEBHOOK_TOKEN", ""),
+		},
+		Policy: PolicyConfig{
+			Dir: getEnv("POLICY_DIR", ""),
+		},
 		Env: getEnv("ENV", "development"),
 	}
 
+	teamStrategies, err := parsePairs(getEnv("REVIEWER_TEAM_STRATEGIES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REVIEWER_TEAM_STRATEGIES: %w", err)
+	}
+
+	rawWeights, err := parsePairs(getEnv("REVIEWER_WEIGHTS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REVIEWER_WEIGHTS: %w", err)
+	}
+	weights := make(map[string]int, len(rawWeights))
+	for userID, raw := range rawWeights {
+		weight, err := strconv.Atoi(raw)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid REVIEWER_WEIGHTS: bad weight %q for %s", raw, userID)
+		}
+		weights[userID] = weight
+	}
+
+	cfg.Webhook, err = loadWebhookConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Outbox, err = loadOutboxConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Absence, err = loadAbsenceConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Reviewer = ReviewerConfig{
+		Strategy:            getEnv("REVIEWER_STRATEGY", "random"),
+		TeamStrategies:      teamStrategies,
+		Weights:             weights,
+		StrictCapacityTeams: parseList(getEnv("REVIEWER_STRICT_CAPACITY_TEAMS", "")),
+	}
+
+	return cfg, nil
+}
+
+// loadWebhookConfig загружает параметры исходящих вебхуков
+func loadWebhookConfig() (WebhookConfig, error) {
+	var cfg WebhookConfig
+	var err error
+
+	if cfg.MaxAttempts, err = getEnvInt("WEBHOOK_MAX_ATTEMPTS", 5); err != nil {
+		return cfg, err
+	}
+	if cfg.Workers, err = getEnvInt("WEBHOOK_WORKERS", 4); err != nil {
+		return cfg, err
+	}
+	if cfg.InitialBackoff, err = getEnvDuration("WEBHOOK_INITIAL_BACKOFF", time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxBackoff, err = getEnvDuration("WEBHOOK_MAX_BACKOFF", time.Minute); err != nil {
+		return cfg, err
+	}
+	if cfg.Timeout, err = getEnvDuration("WEBHOOK_TIMEOUT", 5*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.PollInterval, err = getEnvDuration("WEBHOOK_POLL_INTERVAL", time.Second); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// loadOutboxConfig загружает параметры outbox
+func loadOutboxConfig() (OutboxConfig, error) {
+	cfg := OutboxConfig{
+		LogEvents: getEnv("OUTBOX_LOG_EVENTS", "false") == "true",
+	}
+	var err error
+
+	if cfg.PollInterval, err = getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.BatchSize, err = getEnvInt("OUTBOX_BATCH_SIZE", 100); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxAttempts, err = getEnvInt("OUTBOX_MAX_ATTEMPTS", 20); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// loadAbsenceConfig загружает параметры обработки отсутствий
+func loadAbsenceConfig() (AbsenceConfig, error) {
+	var cfg AbsenceConfig
+	var err error
+
+	if cfg.PollInterval, err = getEnvDuration("ABSENCE_POLL_INTERVAL", time.Minute); err != nil {
+		return cfg, err
+	}
+	if cfg.BatchSize, err = getEnvInt("ABSENCE_BATCH_SIZE", 50); err != nil {
+		return cfg, err
+	}
+	if cfg.RetryDelay, err = getEnvDuration("ABSENCE_RETRY_DELAY", 15*time.Minute); err != nil {
+		return cfg, err
+	}
 	return cfg, nil
 }
 
@@ -78,3 +253,57 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt возвращает положительное целое из переменной окружения
+func getEnvInt(key string, defaultValue int) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid %s: expected positive integer, got %q", key, raw)
+	}
+	return value, nil
+}
+
+// getEnvDuration возвращает длительность из переменной окружения (формат time.ParseDuration)
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid %s: expected positive duration, got %q", key, raw)
+	}
+	return value, nil
+}
+
+// parsePairs разбирает строку вида "key1:value1,key2:value2"
+func parsePairs(raw string) (map[string]string, error) {
+	result := make(map[string]string)
+	if strings.TrimSpace(raw) == "" {
+		return result, nil
+	}
+
+	for _, item := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("expected key:value, got %q", item)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// parseList разбирает список значений через запятую
+func parseList(raw string) []string {
+	var result []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
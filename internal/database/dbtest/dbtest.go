@@ -0,0 +1,100 @@
+// Package dbtest подготавливает базу данных PostgreSQL для интеграционных тестов.
+package dbtest
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	// регистрация драйвера
+	_ "github.com/lib/pq"
+)
+
+// DSNEnv - переменная окружения с DSN тестовой базы данных.
+// Без нее интеграционные тесты пропускаются
+const DSNEnv = "TEST_DATABASE_DSN"
+
+// Open создает в тестовой базе отдельную схему, применяет к ней миграции
+// из migrations/*.up.sql и возвращает подключение, работающее в этой схеме.
+// Схема удаляется после завершения теста
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv(DSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", DSNEnv)
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = admin.Close()
+	})
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Errorf("failed to drop schema: %v", err)
+		}
+	})
+
+	db, err := sql.Open("postgres", withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	for _, path := range migrations(t) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read migration: %v", err)
+		}
+		if _, err := db.Exec(string(data)); err != nil {
+			t.Fatalf("failed to apply %s: %v", filepath.Base(path), err)
+		}
+	}
+	return db
+}
+
+// withSearchPath добавляет search_path к DSN в формате URL или key=value
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + "search_path=" + schema
+	}
+	return dsn + " search_path=" + schema
+}
+
+// migrations возвращает файлы миграций в порядке применения
+func migrations(t testing.TB) []string {
+	t.Helper()
+
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to locate migrations")
+	}
+	dir := filepath.Join(filepath.Dir(file), "..", "..", "..", "migrations")
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("failed to list migrations in %s: %v", dir, err)
+	}
+	sort.Strings(paths)
+	return paths
+}
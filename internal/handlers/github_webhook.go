@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/response"
+	"github.com/zazaza5818/pr-reviewer-service/internal/service"
+)
+
+// githubPullRequestPayload - используемая часть события pull_request GitHub
+type githubPullRequestPayload struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Draft  bool   `json:"draft"`
+		Merged bool   `json:"merged"`
+		User   struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+}
+
+// GitHubWebhook обрабатывает POST /webhooks/github.
+// Запрос аутентифицируется подписью X-Hub-Signature-256, а не JWT
+func (h *VCSHandler) GitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	if len(h.githubSecret) == 0 {
+		response.Error(w, http.StatusNotFound, models.ErrNotFound, "github integration is not configured")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if !verifyGitHubSignature(h.githubSecret, body, r.Header.Get("X-Hub-Signature-256")) {
+		response.Error(w, http.StatusUnauthorized, models.ErrUnauthorized, "invalid signature")
+		return
+	}
+
+	// Остальные типы событий (в том числе ping) принимаются без обработки
+	if r.Header.Get("X-GitHub-Event") != "pull_request" {
+		response.JSON(w, http.StatusOK, service.VCSEventResult{Status: service.VCSResultIgnored})
+		return
+	}
+
+	event, err := parseGitHubPullRequestEvent(body)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, err.Error())
+		return
+	}
+	event.DeliveryID = r.Header.Get("X-GitHub-Delivery")
+
+	h.handleEvent(w, r, event)
+}
+
+// verifyGitHubSignature проверяет HMAC-SHA256 подпись тела в формате "sha256=<hex>"
+func verifyGitHubSignature(secret, body []byte, header string) bool {
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// parseGitHubPullRequestEvent приводит событие pull_request GitHub к общему виду.
+// Для неподдерживаемых действий возвращается событие с пустым Action
+func parseGitHubPullRequestEvent(body []byte) (*models.VCSPullRequestEvent, error) {
+	var payload githubPullRequestPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, errors.New("invalid pull_request payload")
+	}
+
+	if payload.Repository.FullName == "" || payload.PullRequest.Number == 0 {
+		return nil, errors.New("repository.full_name and pull_request.number are required")
+	}
+
+	event := &models.VCSPullRequestEvent{
+		Provider:        models.ProviderGitHub,
+		PullRequestID:   fmt.Sprintf("%s#%d", payload.Repository.FullName, payload.PullRequest.Number),
+		PullRequestName: payload.PullRequest.Title,
+		AuthorLogin:     payload.PullRequest.User.Login,
+		SenderLogin:     payload.Sender.Login,
+		Draft:           payload.PullRequest.Draft,
+	}
+
+	switch payload.Action {
+	case "opened":
+		event.Action = models.VCSActionOpened
+	case "closed":
+		// GitHub присылает слияние как closed с merged=true
+		event.Action = models.VCSActionClosed
+		if payload.PullRequest.Merged {
+			event.Action = models.VCSActionMerged
+		}
+	case "reopened":
+		event.Action = models.VCSActionReopened
+	case "ready_for_review":
+		event.Action = models.VCSActionReady
+	}
+
+	return event, nil
+}
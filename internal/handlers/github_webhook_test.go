@@ -0,0 +1,164 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/handlers"
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/service"
+)
+
+const githubSecret = "test-secret"
+
+// fakeVCSService запоминает события, переданные обработчиком
+type fakeVCSService struct {
+	events []*models.VCSPullRequestEvent
+}
+
+func (s *fakeVCSService) HandlePullRequestEvent(_ context.Context, event *models.VCSPullRequestEvent) (*service.VCSEventResult, error) {
+	s.events = append(s.events, event)
+	return &service.VCSEventResult{Status: service.VCSResultProcessed}, nil
+}
+
+func (s *fakeVCSService) LinkIdentity(context.Context, *models.VCSIdentity) error {
+	return nil
+}
+
+// readFixture читает payload GitHub из testdata/github
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", "github", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	return body
+}
+
+// signGitHub подписывает тело так же, как GitHub в X-Hub-Signature-256
+func signGitHub(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// postGitHub отправляет событие pull_request в обработчик вебхуков GitHub
+func postGitHub(vcs service.VCSService, body []byte, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader(body))
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+	req.Header.Set("X-Hub-Signature-256", signature)
+
+	rec := httptest.NewRecorder()
+	handlers.NewVCSHandler(vcs, githubSecret, "").GitHubWebhook(rec, req)
+	return rec
+}
+
+func TestGitHubWebhookSignature(t *testing.T) {
+	body := readFixture(t, "pull_request_opened.json")
+
+	tests := []struct {
+		name       string
+		signature  string
+		wantStatus int
+	}{
+		{name: "valid", signature: signGitHub(githubSecret, body), wantStatus: http.StatusOK},
+		{name: "wrong secret", signature: signGitHub("other-secret", body), wantStatus: http.StatusUnauthorized},
+		{name: "not hex", signature: "sha256=zz", wantStatus: http.StatusUnauthorized},
+		{name: "no prefix", signature: signGitHub(githubSecret, body)[len("sha256="):], wantStatus: http.StatusUnauthorized},
+		{name: "missing", signature: "", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcs := &fakeVCSService{}
+			rec := postGitHub(vcs, body, tt.signature)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			wantEvents := 0
+			if tt.wantStatus == http.StatusOK {
+				wantEvents = 1
+			}
+			if len(vcs.events) != wantEvents {
+				t.Errorf("service received %d events, want %d", len(vcs.events), wantEvents)
+			}
+		})
+	}
+}
+
+func TestGitHubWebhookActions(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		wantAction models.VCSAction
+		wantDraft  bool
+		wantSender string
+	}{
+		{fixture: "pull_request_opened.json", wantAction: models.VCSActionOpened, wantSender: "octocat"},
+		{fixture: "pull_request_opened_draft.json", wantAction: models.VCSActionOpened, wantDraft: true, wantSender: "octocat"},
+		{fixture: "pull_request_closed.json", wantAction: models.VCSActionClosed, wantSender: "hubot"},
+		{fixture: "pull_request_closed_merged.json", wantAction: models.VCSActionMerged, wantSender: "hubot"},
+		{fixture: "pull_request_reopened.json", wantAction: models.VCSActionReopened, wantSender: "hubot"},
+		{fixture: "pull_request_ready_for_review.json", wantAction: models.VCSActionReady, wantSender: "octocat"},
+		// неподдерживаемые действия передаются без Action, и сервис их игнорирует
+		{fixture: "pull_request_labeled.json", wantAction: "", wantSender: "hubot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			body := readFixture(t, tt.fixture)
+			vcs := &fakeVCSService{}
+			rec := postGitHub(vcs, body, signGitHub(githubSecret, body))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+			}
+			if len(vcs.events) != 1 {
+				t.Fatalf("service received %d events, want 1", len(vcs.events))
+			}
+
+			event := vcs.events[0]
+			want := models.VCSPullRequestEvent{
+				Provider:        models.ProviderGitHub,
+				DeliveryID:      "72d3162e-cc78-11e3-81ab-4c9367dc0958",
+				Action:          tt.wantAction,
+				PullRequestID:   "acme/widgets#42",
+				PullRequestName: "Add search endpoint",
+				AuthorLogin:     "octocat",
+				SenderLogin:     tt.wantSender,
+				Draft:           tt.wantDraft,
+			}
+			if *event != want {
+				t.Errorf("event = %+v, want %+v", *event, want)
+			}
+		})
+	}
+}
+
+func TestGitHubWebhookIgnoresOtherEvents(t *testing.T) {
+	body := []byte(`{"zen":"Keep it logically awesome.","hook_id":1}`)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader(body))
+	req.Header.Set("X-GitHub-Event", "ping")
+	req.Header.Set("X-Hub-Signature-256", signGitHub(githubSecret, body))
+
+	vcs := &fakeVCSService{}
+	rec := httptest.NewRecorder()
+	handlers.NewVCSHandler(vcs, githubSecret, "").GitHubWebhook(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if len(vcs.events) != 0 {
+		t.Errorf("service received %d events, want 0", len(vcs.events))
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/response"
+	"github.com/zazaza5818/pr-reviewer-service/internal/service"
+)
+
+// gitlabMergeRequestPayload - используемая часть события Merge Request Hook GitLab
+type gitlabMergeRequestPayload struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		Action string `json:"action"`
+		Draft  bool   `json:"draft"`
+	} `json:"object_attributes"`
+	Changes struct {
+		Draft *struct {
+			Previous bool `json:"previous"`
+			Current  bool `json:"current"`
+		} `json:"draft"`
+	} `json:"changes"`
+}
+
+// GitLabWebhook обрабатывает POST /webhooks/gitlab.
+// Запрос аутентифицируется токеном X-Gitlab-Token, а не JWT
+func (h *VCSHandler) GitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	if len(h.gitlabToken) == 0 {
+		response.Error(w, http.StatusNotFound, models.ErrNotFound, "gitlab integration is not configured")
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), h.gitlabToken) != 1 {
+		response.Error(w, http.StatusUnauthorized, models.ErrUnauthorized, "invalid token")
+		return
+	}
+
+	// Остальные типы событий принимаются без обработки
+	if r.Header.Get("X-Gitlab-Event") != "Merge Request Hook" {
+		response.JSON(w, http.StatusOK, service.VCSEventResult{Status: service.VCSResultIgnored})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	event, err := parseGitLabMergeRequestEvent(body)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, err.Error())
+		return
+	}
+
+	// Idempotency-Key сохраняется между повторами доставки,
+	// старые версии GitLab передают только X-Gitlab-Event-UUID
+	event.DeliveryID = r.Header.Get("Idempotency-Key")
+	if event.DeliveryID == "" {
+		event.DeliveryID = r.Header.Get("X-Gitlab-Event-UUID")
+	}
+
+	h.handleEvent(w, r, event)
+}
+
+// parseGitLabMergeRequestEvent приводит Merge Request Hook GitLab к общему виду.
+// Для неподдерживаемых действий возвращается событие с пустым Action
+func parseGitLabMergeRequestEvent(body []byte) (*models.VCSPullRequestEvent, error) {
+	var payload gitlabMergeRequestPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, errors.New("invalid merge_request payload")
+	}
+
+	if payload.Project.PathWithNamespace == "" || payload.ObjectAttributes.IID == 0 {
+		return nil, errors.New("project.path_with_namespace and object_attributes.iid are required")
+	}
+
+	// GitLab не передает логин автора MR, только числовой author_id.
+	// Событие open отправляет сам автор, поэтому используется логин отправителя
+	event := &models.VCSPullRequestEvent{
+		Provider:        models.ProviderGitLab,
+		PullRequestID:   fmt.Sprintf("%s!%d", payload.Project.PathWithNamespace, payload.ObjectAttributes.IID),
+		PullRequestName: payload.ObjectAttributes.Title,
+		AuthorLogin:     payload.User.Username,
+		SenderLogin:     payload.User.Username,
+		Draft:           payload.ObjectAttributes.Draft,
+	}
+
+	switch payload.ObjectAttributes.Action {
+	case "open":
+		event.Action = models.VCSActionOpened
+	case "update":
+		// Из обновлений обрабатывается только снятие статуса черновика
+		if draft := payload.Changes.Draft; draft != nil && draft.Previous && !draft.Current {
+			event.Action = models.VCSActionReady
+		}
+	case "merge":
+		event.Action = models.VCSActionMerged
+	case "close":
+		event.Action = models.VCSActionClosed
+	case "reopen":
+		event.Action = models.VCSActionReopened
+	}
+
+	return event, nil
+}
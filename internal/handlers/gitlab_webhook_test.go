@@ -0,0 +1,174 @@
+package handlers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/handlers"
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/service"
+)
+
+const gitlabToken = "test-token"
+
+// readGitLabFixture читает payload GitLab из testdata/gitlab
+func readGitLabFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", "gitlab", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	return body
+}
+
+// newGitLabRequest создает событие Merge Request Hook с токеном token
+func newGitLabRequest(body []byte, token string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/gitlab", bytes.NewReader(body))
+	req.Header.Set("X-Gitlab-Event", "Merge Request Hook")
+	req.Header.Set("X-Gitlab-Token", token)
+	req.Header.Set("Idempotency-Key", "2f6a9d0e-3c1b-4f7e-9a55-0d2c8b7e6f41")
+	return req
+}
+
+// postGitLab отправляет запрос в обработчик вебхуков GitLab
+func postGitLab(vcs service.VCSService, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handlers.NewVCSHandler(vcs, githubSecret, gitlabToken).GitLabWebhook(rec, req)
+	return rec
+}
+
+func TestGitLabWebhookToken(t *testing.T) {
+	body := readGitLabFixture(t, "merge_request_open.json")
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "valid", token: gitlabToken, wantStatus: http.StatusOK},
+		{name: "wrong token", token: "other-token", wantStatus: http.StatusUnauthorized},
+		{name: "token prefix", token: gitlabToken[:4], wantStatus: http.StatusUnauthorized},
+		{name: "missing", token: "", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcs := &fakeVCSService{}
+			rec := postGitLab(vcs, newGitLabRequest(body, tt.token))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			wantEvents := 0
+			if tt.wantStatus == http.StatusOK {
+				wantEvents = 1
+			}
+			if len(vcs.events) != wantEvents {
+				t.Errorf("service received %d events, want %d", len(vcs.events), wantEvents)
+			}
+		})
+	}
+}
+
+func TestGitLabWebhookNotConfigured(t *testing.T) {
+	// Без токена интеграция выключена, и пустой X-Gitlab-Token не проходит
+	body := readGitLabFixture(t, "merge_request_open.json")
+	vcs := &fakeVCSService{}
+	rec := httptest.NewRecorder()
+	handlers.NewVCSHandler(vcs, githubSecret, "").GitLabWebhook(rec, newGitLabRequest(body, ""))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if len(vcs.events) != 0 {
+		t.Errorf("service received %d events, want 0", len(vcs.events))
+	}
+}
+
+func TestGitLabWebhookActions(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		wantAction models.VCSAction
+		wantDraft  bool
+		wantSender string
+	}{
+		{fixture: "merge_request_open.json", wantAction: models.VCSActionOpened, wantSender: "jsmith"},
+		{fixture: "merge_request_open_draft.json", wantAction: models.VCSActionOpened, wantDraft: true, wantSender: "jsmith"},
+		{fixture: "merge_request_update_ready.json", wantAction: models.VCSActionReady, wantSender: "jsmith"},
+		{fixture: "merge_request_merge.json", wantAction: models.VCSActionMerged, wantSender: "releasebot"},
+		{fixture: "merge_request_close.json", wantAction: models.VCSActionClosed, wantSender: "releasebot"},
+		{fixture: "merge_request_reopen.json", wantAction: models.VCSActionReopened, wantSender: "releasebot"},
+		// прочие обновления передаются без Action, и сервис их игнорирует
+		{fixture: "merge_request_update_title.json", wantAction: "", wantSender: "releasebot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			body := readGitLabFixture(t, tt.fixture)
+			vcs := &fakeVCSService{}
+			rec := postGitLab(vcs, newGitLabRequest(body, gitlabToken))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+			}
+			if len(vcs.events) != 1 {
+				t.Fatalf("service received %d events, want 1", len(vcs.events))
+			}
+
+			// GitLab не передает логин автора, поэтому им считается отправитель
+			event := vcs.events[0]
+			want := models.VCSPullRequestEvent{
+				Provider:        models.ProviderGitLab,
+				DeliveryID:      "2f6a9d0e-3c1b-4f7e-9a55-0d2c8b7e6f41",
+				Action:          tt.wantAction,
+				PullRequestID:   "acme/widgets!42",
+				PullRequestName: "Add search endpoint",
+				AuthorLogin:     tt.wantSender,
+				SenderLogin:     tt.wantSender,
+				Draft:           tt.wantDraft,
+			}
+			if *event != want {
+				t.Errorf("event = %+v, want %+v", *event, want)
+			}
+		})
+	}
+}
+
+func TestGitLabWebhookDeliveryID(t *testing.T) {
+	// Старые версии GitLab не передают Idempotency-Key
+	body := readGitLabFixture(t, "merge_request_open.json")
+	req := newGitLabRequest(body, gitlabToken)
+	req.Header.Del("Idempotency-Key")
+	req.Header.Set("X-Gitlab-Event-UUID", "6b1b3e4c-8f0a-4d2e-b7c9-1a2b3c4d5e6f")
+
+	vcs := &fakeVCSService{}
+	rec := postGitLab(vcs, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if len(vcs.events) != 1 || vcs.events[0].DeliveryID != "6b1b3e4c-8f0a-4d2e-b7c9-1a2b3c4d5e6f" {
+		t.Errorf("events = %+v, want one event with the X-Gitlab-Event-UUID delivery", vcs.events)
+	}
+}
+
+func TestGitLabWebhookIgnoresOtherEvents(t *testing.T) {
+	body := []byte(`{"object_kind":"push","ref":"refs/heads/main"}`)
+	req := newGitLabRequest(body, gitlabToken)
+	req.Header.Set("X-Gitlab-Event", "Push Hook")
+
+	vcs := &fakeVCSService{}
+	rec := postGitLab(vcs, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if len(vcs.events) != 0 {
+		t.Errorf("service received %d events, want 0", len(vcs.events))
+	}
+}
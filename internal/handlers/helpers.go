@@ -2,9 +2,12 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 
+	"github.com/zazaza5818/pr-reviewer-service/internal/middleware"
 	"github.com/zazaza5818/pr-reviewer-service/internal/response"
+	"github.com/zazaza5818/pr-reviewer-service/internal/service"
 )
 
 // HealthHandler обрабатывает проверку здоровья сервиса
@@ -21,3 +24,10 @@ func (h *HealthHandler) Check(w http.ResponseWriter, _ *http.Request) {
 		"status": "ok",
 	})
 }
+
+// actorContext возвращает контекст запроса с пользователем из JWT токена
+// в качестве автора операции
+func actorContext(r *http.Request) context.Context {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	return service.WithActor(r.Context(), userID)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/response"
+	"github.com/zazaza5818/pr-reviewer-service/internal/service"
+)
+
+// OwnershipHandler обрабатывает запросы к правилам владения кодом
+type OwnershipHandler struct {
+	service service.OwnershipService
+}
+
+// NewOwnershipHandler создает новый обработчик правил владения кодом
+func NewOwnershipHandler(service service.OwnershipService) *OwnershipHandler {
+	return &OwnershipHandler{service: service}
+}
+
+// ListRules обрабатывает GET /ownership/rules
+func (h *OwnershipHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.service.ListRules(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to list ownership rules")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"rules": rules,
+	})
+}
+
+// CreateRule обрабатывает POST /ownership/rules
+func (h *OwnershipHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.OwnershipRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if rule.Pattern == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "pattern is required")
+		return
+	}
+
+	created, err := h.service.CreateRule(r.Context(), &models.OwnershipRule{
+		Pattern: rule.Pattern,
+		Users:   rule.Users,
+		Teams:   rule.Teams,
+	})
+	if err != nil {
+		writeOwnershipError(w, err, "failed to create ownership rule")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, map[string]interface{}{
+		"rule": created,
+	})
+}
+
+// UpdateRule обрабатывает POST /ownership/rules/update
+func (h *OwnershipHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.OwnershipRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if rule.ID <= 0 || rule.Pattern == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "id and pattern are required")
+		return
+	}
+
+	updated, err := h.service.UpdateRule(r.Context(), &rule)
+	if err != nil {
+		writeOwnershipError(w, err, "failed to update ownership rule")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"rule": updated,
+	})
+}
+
+// DeleteRule обрабатывает POST /ownership/rules/delete
+func (h *OwnershipHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.ID <= 0 {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "id is required")
+		return
+	}
+
+	if err := h.service.DeleteRule(r.Context(), req.ID); err != nil {
+		writeOwnershipError(w, err, "failed to delete ownership rule")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"id": req.ID,
+	})
+}
+
+// writeOwnershipError отвечает ошибкой изменения правил владения
+func writeOwnershipError(w http.ResponseWriter, err error, failMessage string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidOwnershipRule):
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "pattern must be a valid glob and owners must be non-empty and unique")
+	case errors.Is(err, service.ErrOwnershipRuleNotFound):
+		response.Error(w, http.StatusNotFound, models.ErrNotFound, "ownership rule not found")
+	case errors.Is(err, service.ErrUserNotFound):
+		response.Error(w, http.StatusNotFound, models.ErrNotFound, "user not found")
+	case errors.Is(err, service.ErrTeamNotFound):
+		response.Error(w, http.StatusNotFound, models.ErrNotFound, "team not found")
+	default:
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, failMessage)
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/response"
+	"github.com/zazaza5818/pr-reviewer-service/internal/service"
+)
+
+// maxPolicyBodySize - максимальный размер документа политики
+const maxPolicyBodySize = 64 << 10
+
+// PolicyHandler обрабатывает запросы к политикам назначения ревьюверов
+type PolicyHandler struct {
+	service service.PolicyService
+}
+
+// NewPolicyHandler создает новый обработчик политик
+func NewPolicyHandler(service service.PolicyService) *PolicyHandler {
+	return &PolicyHandler{service: service}
+}
+
+// Validate обрабатывает POST /policy/validate. Политика только проверяется
+// и не применяется; результат проверки возвращается с кодом 200
+func (h *PolicyHandler) Validate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPolicyBodySize))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "policy document must be at most 64KB")
+		return
+	}
+
+	validation, err := h.service.Validate(r.Context(), body)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to validate policy")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, validation)
+}
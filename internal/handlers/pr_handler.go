@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 
+	"github.com/zazaza5818/pr-reviewer-service/internal/middleware"
 	"github.com/zazaza5818/pr-reviewer-service/internal/models"
 	"github.com/zazaza5818/pr-reviewer-service/internal/response"
 	"github.com/zazaza5818/pr-reviewer-service/internal/service"
@@ -20,12 +22,26 @@ func NewPRHandler(service service.PullRequestService) *PRHandler {
 	return &PRHandler{service: service}
 }
 
+// maxChangedFiles - сколько путей можно передать при создании PR
+const maxChangedFiles = 1000
+
+// invalidTagsMessage - описание ошибки для некорректных навыков и тегов
+const invalidTagsMessage = "expected at most 20 distinct tags of lowercase letters, digits and +#._- up to 32 chars"
+
+// noSeniorCandidateMessage - описание ошибки, когда не хватает ревьюверов требуемого уровня
+const noSeniorCandidateMessage = "not enough candidates of the seniority required by team"
+
 // CreatePR обрабатывает POST /pullRequest/create
 func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		PullRequestID   string `json:"pull_request_id"`
 		PullRequestName string `json:"pull_request_name"`
 		AuthorID        string `json:"author_id"`
+		Draft           bool   `json:"draft"`
+		// ChangedFiles - пути, измененные в PR, для выбора владельцев кода
+		ChangedFiles []string `json:"changed_files"`
+		// Tags - области PR, по которым подбираются ревьюверы с навыками
+		Tags []string `json:"tags"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -38,10 +54,31 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "pull_request_id, pull_request_name, and author_id are required")
 		return
 	}
+	if len(req.ChangedFiles) > maxChangedFiles {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "changed_files must contain at most 1000 paths")
+		return
+	}
+	for _, path := range req.ChangedFiles {
+		if path == "" {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "changed_files must not contain empty paths")
+			return
+		}
+	}
 
-	ctx := r.Context()
-	pr, err := h.service.CreatePullRequest(ctx, req.PullRequestID, req.PullRequestName, req.AuthorID)
+	ctx := actorContext(r)
+	pr, err := h.service.CreatePullRequest(ctx, service.CreatePullRequestParams{
+		PullRequestID:   req.PullRequestID,
+		PullRequestName: req.PullRequestName,
+		AuthorID:        req.AuthorID,
+		Draft:           req.Draft,
+		ChangedFiles:    req.ChangedFiles,
+		Tags:            req.Tags,
+	})
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidTags) {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, invalidTagsMessage)
+			return
+		}
 		if errors.Is(err, service.ErrPRExists) {
 			response.Error(w, http.StatusConflict, models.ErrPRExists, "PR id already exists")
 			return
@@ -50,6 +87,22 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 			response.Error(w, http.StatusNotFound, models.ErrNotFound, "author not found")
 			return
 		}
+		if errors.Is(err, service.ErrTeamNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "author does not belong to any team")
+			return
+		}
+		if errors.Is(err, service.ErrCapacityExhausted) {
+			response.Error(w, http.StatusConflict, models.ErrCapacityExhausted, "all candidates reached their open review limit")
+			return
+		}
+		if errors.Is(err, service.ErrNotEnoughReviewers) {
+			response.Error(w, http.StatusConflict, models.ErrNotEnoughReviewers, "not enough candidates to satisfy team min_reviewers")
+			return
+		}
+		if errors.Is(err, service.ErrNoSeniorCandidate) {
+			response.Error(w, http.StatusConflict, models.ErrNoSeniorCandidate, noSeniorCandidateMessage)
+			return
+		}
 		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to create pull request")
 		return
 	}
@@ -59,10 +112,47 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// SetTags обрабатывает POST /pullRequest/setTags
+func (h *PRHandler) SetTags(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PullRequestID string   `json:"pull_request_id"`
+		Tags          []string `json:"tags"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.PullRequestID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "pull_request_id is required")
+		return
+	}
+
+	pr, err := h.service.SetTags(actorContext(r), req.PullRequestID, req.Tags)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTags) {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, invalidTagsMessage)
+			return
+		}
+		if errors.Is(err, service.ErrPRNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "pull request not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to set tags")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"pr": pr,
+	})
+}
+
 // MergePR обрабатывает POST /pullRequest/merge
 func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		PullRequestID string `json:"pull_request_id"`
+		Force         bool   `json:"force"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -75,13 +165,25 @@ func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := r.Context()
-	pr, err := h.service.MergePullRequest(ctx, req.PullRequestID)
+	ctx := actorContext(r)
+	pr, err := h.service.MergePullRequest(ctx, req.PullRequestID, req.Force)
 	if err != nil {
 		if errors.Is(err, service.ErrPRNotFound) {
 			response.Error(w, http.StatusNotFound, models.ErrNotFound, "pull request not found")
 			return
 		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "author not found")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidStatus) {
+			response.Error(w, http.StatusConflict, models.ErrInvalidStatus, "only OPEN pull request can be merged")
+			return
+		}
+		if errors.Is(err, service.ErrApprovalsRequired) {
+			response.Error(w, http.StatusConflict, models.ErrApprovalsRequired, "pull request lacks required approvals")
+			return
+		}
 		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to merge pull request")
 		return
 	}
@@ -91,11 +193,144 @@ func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// SubmitReview обрабатывает POST /pullRequest/review.
+// Решение принимается от имени пользователя из JWT токена
+func (h *PRHandler) SubmitReview(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PullRequestID string                `json:"pull_request_id"`
+		Decision      models.ReviewDecision `json:"decision"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.PullRequestID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "pull_request_id is required")
+		return
+	}
+
+	if req.Decision != models.DecisionApproved && req.Decision != models.DecisionChangesRequested {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "decision must be APPROVED or CHANGES_REQUESTED")
+		return
+	}
+
+	ctx := r.Context()
+	reviewerID, _ := ctx.Value(middleware.UserIDKey).(string)
+	if reviewerID == "" {
+		response.Error(w, http.StatusUnauthorized, models.ErrUnauthorized, "token does not contain user_id")
+		return
+	}
+
+	review, err := h.service.SubmitReview(ctx, req.PullRequestID, reviewerID, req.Decision)
+	if err != nil {
+		if errors.Is(err, service.ErrPRNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "pull request not found")
+			return
+		}
+		if errors.Is(err, service.ErrPRMerged) {
+			response.Error(w, http.StatusConflict, models.ErrPRMerged, "cannot review merged PR")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidStatus) {
+			response.Error(w, http.StatusConflict, models.ErrInvalidStatus, "only OPEN pull request can be reviewed")
+			return
+		}
+		if errors.Is(err, service.ErrReviewerNotFound) {
+			response.Error(w, http.StatusConflict, models.ErrNotAssigned, "reviewer is not assigned to this PR")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to submit review")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"review": review,
+	})
+}
+
+// ClosePR обрабатывает POST /pullRequest/close
+func (h *PRHandler) ClosePR(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.service.ClosePullRequest, "failed to close pull request")
+}
+
+// ReopenPR обрабатывает POST /pullRequest/reopen
+func (h *PRHandler) ReopenPR(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.service.ReopenPullRequest, "failed to reopen pull request")
+}
+
+// MarkReady обрабатывает POST /pullRequest/markReady
+func (h *PRHandler) MarkReady(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.service.MarkReady, "failed to mark pull request as ready")
+}
+
+// changeStatus выполняет смену статуса PR по запросу вида {"pull_request_id": "..."}
+func (h *PRHandler) changeStatus(
+	w http.ResponseWriter,
+	r *http.Request,
+	change func(ctx context.Context, prID string) (*models.PullRequest, error),
+	failMessage string,
+) {
+	var req struct {
+		PullRequestID string `json:"pull_request_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.PullRequestID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "pull_request_id is required")
+		return
+	}
+
+	pr, err := change(actorContext(r), req.PullRequestID)
+	if err != nil {
+		if errors.Is(err, service.ErrPRNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "pull request not found")
+			return
+		}
+		if errors.Is(err, service.ErrPRMerged) {
+			response.Error(w, http.StatusConflict, models.ErrPRMerged, "cannot change status of merged PR")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidStatus) {
+			response.Error(w, http.StatusConflict, models.ErrInvalidStatus, "status transition is not allowed")
+			return
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "author not found")
+			return
+		}
+		if errors.Is(err, service.ErrCapacityExhausted) {
+			response.Error(w, http.StatusConflict, models.ErrCapacityExhausted, "all candidates reached their open review limit")
+			return
+		}
+		if errors.Is(err, service.ErrNotEnoughReviewers) {
+			response.Error(w, http.StatusConflict, models.ErrNotEnoughReviewers, "not enough candidates to satisfy team min_reviewers")
+			return
+		}
+		if errors.Is(err, service.ErrNoSeniorCandidate) {
+			response.Error(w, http.StatusConflict, models.ErrNoSeniorCandidate, noSeniorCandidateMessage)
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, failMessage)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"pr": pr,
+	})
+}
+
 // ReassignPR обрабатывает POST /pullRequest/reassign
 func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		PullRequestID string `json:"pull_request_id"`
 		OldUserID     string `json:"old_user_id"`
+		Reason        string `json:"reason"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -108,8 +343,8 @@ func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := r.Context()
-	pr, newReviewerID, err := h.service.ReassignReviewer(ctx, req.PullRequestID, req.OldUserID)
+	ctx := actorContext(r)
+	pr, newReviewerID, err := h.service.ReassignReviewer(ctx, req.PullRequestID, req.OldUserID, req.Reason)
 	if err != nil {
 		if errors.Is(err, service.ErrPRNotFound) {
 			response.Error(w, http.StatusNotFound, models.ErrNotFound, "pull request not found")
@@ -119,6 +354,10 @@ func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 			response.Error(w, http.StatusConflict, models.ErrPRMerged, "cannot reassign on merged PR")
 			return
 		}
+		if errors.Is(err, service.ErrInvalidStatus) {
+			response.Error(w, http.StatusConflict, models.ErrInvalidStatus, "reviewers can be reassigned only on OPEN PR")
+			return
+		}
 		if errors.Is(err, service.ErrReviewerNotFound) {
 			response.Error(w, http.StatusConflict, models.ErrNotAssigned, "reviewer is not assigned to this PR")
 			return
@@ -127,6 +366,14 @@ func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 			response.Error(w, http.StatusConflict, models.ErrNoCandidate, "no active replacement candidate in team")
 			return
 		}
+		if errors.Is(err, service.ErrNoSeniorCandidate) {
+			response.Error(w, http.StatusConflict, models.ErrNoSeniorCandidate, noSeniorCandidateMessage)
+			return
+		}
+		if errors.Is(err, service.ErrCapacityExhausted) {
+			response.Error(w, http.StatusConflict, models.ErrCapacityExhausted, "all candidates reached their open review limit")
+			return
+		}
 		if errors.Is(err, service.ErrUserNotFound) {
 			response.Error(w, http.StatusNotFound, models.ErrNotFound, "user not found")
 			return
@@ -140,3 +387,28 @@ func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 		"replaced_by": newReviewerID,
 	})
 }
+
+// GetHistory обрабатывает GET /pullRequest/history?pull_request_id=...
+func (h *PRHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "pull_request_id query parameter is required")
+		return
+	}
+
+	ctx := r.Context()
+	events, err := h.service.GetHistory(ctx, prID)
+	if err != nil {
+		if errors.Is(err, service.ErrPRNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "pull request not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to get pull request history")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"pull_request_id": prID,
+		"events":          events,
+	})
+}
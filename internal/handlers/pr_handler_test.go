@@ -0,0 +1,58 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/handlers"
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/service"
+)
+
+// fakeMergeService возвращает заданную ошибку при мерже PR
+type fakeMergeService struct {
+	service.PullRequestService
+	err error
+}
+
+func (s *fakeMergeService) MergePullRequest(context.Context, string, bool) (*models.PullRequest, error) {
+	return nil, s.err
+}
+
+func TestMergePRErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   models.ErrorCode
+	}{
+		{name: "pull request not found", err: service.ErrPRNotFound, wantStatus: http.StatusNotFound, wantCode: models.ErrNotFound},
+		// Автор удален: проверка одобрений не может найти его команду
+		{name: "author not found", err: service.ErrUserNotFound, wantStatus: http.StatusNotFound, wantCode: models.ErrNotFound},
+		{name: "not open", err: service.ErrInvalidStatus, wantStatus: http.StatusConflict, wantCode: models.ErrInvalidStatus},
+		{name: "approvals required", err: service.ErrApprovalsRequired, wantStatus: http.StatusConflict, wantCode: models.ErrApprovalsRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/pullRequest/merge", strings.NewReader(`{"pull_request_id":"pr-1"}`))
+			rec := httptest.NewRecorder()
+			handlers.NewPRHandler(&fakeMergeService{err: tt.err}).MergePR(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var body models.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if body.Error.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", body.Error.Code, tt.wantCode)
+			}
+		})
+	}
+}
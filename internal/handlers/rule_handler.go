@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/response"
+	"github.com/zazaza5818/pr-reviewer-service/internal/service"
+)
+
+// RuleHandler обрабатывает запросы к правилам назначения ревьюверов
+type RuleHandler struct {
+	service service.RuleService
+}
+
+// NewRuleHandler создает новый обработчик правил назначения ревьюверов
+func NewRuleHandler(service service.RuleService) *RuleHandler {
+	return &RuleHandler{service: service}
+}
+
+// ListRules обрабатывает GET /rules
+func (h *RuleHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.service.ListRules(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to list reviewer rules")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"rules": rules,
+	})
+}
+
+// CreateRule обрабатывает POST /rules
+func (h *RuleHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.ReviewerRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if rule.Kind == "" || rule.UserID == "" || rule.TargetID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "kind, user_id and target_id are required")
+		return
+	}
+
+	created, err := h.service.CreateRule(r.Context(), &models.ReviewerRule{
+		Kind:     rule.Kind,
+		UserID:   rule.UserID,
+		TargetID: rule.TargetID,
+		Comment:  rule.Comment,
+	})
+	if err != nil {
+		writeRuleError(w, err, "failed to create reviewer rule")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, map[string]interface{}{
+		"rule": created,
+	})
+}
+
+// UpdateRule обрабатывает POST /rules/update
+func (h *RuleHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.ReviewerRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if rule.ID <= 0 || rule.Kind == "" || rule.UserID == "" || rule.TargetID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "id, kind, user_id and target_id are required")
+		return
+	}
+
+	updated, err := h.service.UpdateRule(r.Context(), &rule)
+	if err != nil {
+		writeRuleError(w, err, "failed to update reviewer rule")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"rule": updated,
+	})
+}
+
+// DeleteRule обрабатывает POST /rules/delete
+func (h *RuleHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.ID <= 0 {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "id is required")
+		return
+	}
+
+	if err := h.service.DeleteRule(r.Context(), req.ID); err != nil {
+		writeRuleError(w, err, "failed to delete reviewer rule")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"id": req.ID,
+	})
+}
+
+// writeRuleError отвечает ошибкой изменения правил назначения ревьюверов
+func writeRuleError(w http.ResponseWriter, err error, failMessage string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidReviewerRule):
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "kind must be EXCLUDE or PAIR, users must differ and the rule must not already exist")
+	case errors.Is(err, service.ErrReviewerRuleNotFound):
+		response.Error(w, http.StatusNotFound, models.ErrNotFound, "reviewer rule not found")
+	case errors.Is(err, service.ErrUserNotFound):
+		response.Error(w, http.StatusNotFound, models.ErrNotFound, "user not found")
+	case errors.Is(err, service.ErrTeamNotFound):
+		response.Error(w, http.StatusNotFound, models.ErrNotFound, "team not found")
+	default:
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, failMessage)
+	}
+}
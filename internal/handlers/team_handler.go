@@ -40,11 +40,21 @@ func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Валидация членов команды
+	seen := make(map[string]bool, len(team.Members))
 	for _, member := range team.Members {
 		if member.UserID == "" || member.Username == "" {
 			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "user_id and username are required for all members")
 			return
 		}
+		if seen[member.UserID] {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "duplicate user_id in members")
+			return
+		}
+		seen[member.UserID] = true
+		if member.MaxOpenReviews != nil && *member.MaxOpenReviews < 0 {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "max_open_reviews must be non-negative")
+			return
+		}
 	}
 
 	ctx := r.Context()
@@ -53,6 +63,10 @@ func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 			response.Error(w, http.StatusBadRequest, models.ErrTeamExists, "team_name already exists")
 			return
 		}
+		if errors.Is(err, service.ErrMemberOfAnotherTeam) {
+			response.Error(w, http.StatusConflict, models.ErrMemberOfAnotherTeam, "user is a member of another team, use /team/moveMember")
+			return
+		}
 		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to create team")
 		return
 	}
@@ -90,3 +104,349 @@ func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 
 	response.JSON(w, http.StatusOK, team)
 }
+
+// GetSettings обрабатывает GET /team/settings?team_name=...
+func (h *TeamHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "team_name query parameter is required")
+		return
+	}
+
+	ctx := r.Context()
+	settings, err := h.service.GetSettings(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, service.ErrTeamNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "team not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to get team settings")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"settings": settings,
+	})
+}
+
+// UpdateSettings обрабатывает POST /team/settings. Поля, которых нет
+// в запросе, сохраняют текущие значения
+func (h *TeamHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var update models.TeamSettingsUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if update.TeamName == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "team_name is required")
+		return
+	}
+
+	ctx := r.Context()
+	updated, err := h.service.UpdateSettings(ctx, &update)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSettings) {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "expected 0 <= min_reviewers <= max_reviewers <= 10, 0 <= required_approvals <= max_reviewers "+
+				"and 0 <= required_seniority_count <= max_reviewers with JUNIOR, MIDDLE or SENIOR required_seniority")
+			return
+		}
+		if errors.Is(err, service.ErrTeamNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "team not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to update team settings")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"settings": updated,
+	})
+}
+
+// GetFallbacks обрабатывает GET /team/fallbacks?team_name=...
+func (h *TeamHandler) GetFallbacks(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "team_name query parameter is required")
+		return
+	}
+
+	ctx := r.Context()
+	teams, err := h.service.GetFallbackTeams(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, service.ErrTeamNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "team not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to get fallback teams")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"team_name":      teamName,
+		"fallback_teams": teams,
+	})
+}
+
+// SetFallbacks обрабатывает POST /team/fallbacks
+func (h *TeamHandler) SetFallbacks(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName      string   `json:"team_name"`
+		FallbackTeams []string `json:"fallback_teams"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "team_name is required")
+		return
+	}
+
+	ctx := r.Context()
+	teams, err := h.service.SetFallbackTeams(ctx, req.TeamName, req.FallbackTeams)
+	if err != nil {
+		if errors.Is(err, service.ErrTeamNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "team not found")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidFallback) {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "fallback teams must exist, be unique and differ from the team itself")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to set fallback teams")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"team_name":      req.TeamName,
+		"fallback_teams": teams,
+	})
+}
+
+// AddMember обрабатывает POST /team/addMember
+func (h *TeamHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName string            `json:"team_name"`
+		Member   models.TeamMember `json:"member"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" || req.Member.UserID == "" || req.Member.Username == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "team_name, member.user_id and member.username are required")
+		return
+	}
+	if req.Member.MaxOpenReviews != nil && *req.Member.MaxOpenReviews < 0 {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "max_open_reviews must be non-negative")
+		return
+	}
+
+	ctx := actorContext(r)
+	team, err := h.service.AddMember(ctx, req.TeamName, req.Member)
+	if err != nil {
+		writeMembershipError(w, err, "failed to add team member")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"team": team,
+	})
+}
+
+// RemoveMember обрабатывает POST /team/removeMember
+func (h *TeamHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName string `json:"team_name"`
+		UserID   string `json:"user_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" || req.UserID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "team_name and user_id are required")
+		return
+	}
+
+	ctx := actorContext(r)
+	report, err := h.service.RemoveMember(ctx, req.TeamName, req.UserID)
+	if err != nil {
+		writeMembershipError(w, err, "failed to remove team member")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"team_name":    req.TeamName,
+		"user_id":      req.UserID,
+		"reassignment": report,
+	})
+}
+
+// MoveMember обрабатывает POST /team/moveMember
+func (h *TeamHandler) MoveMember(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName    string `json:"team_name"`
+		UserID      string `json:"user_id"`
+		NewTeamName string `json:"new_team_name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" || req.UserID == "" || req.NewTeamName == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "team_name, user_id and new_team_name are required")
+		return
+	}
+
+	if req.TeamName == req.NewTeamName {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "new_team_name must differ from team_name")
+		return
+	}
+
+	ctx := actorContext(r)
+	report, err := h.service.MoveMember(ctx, req.TeamName, req.UserID, req.NewTeamName)
+	if err != nil {
+		writeMembershipError(w, err, "failed to move team member")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"team_name":     req.TeamName,
+		"user_id":       req.UserID,
+		"new_team_name": req.NewTeamName,
+		"reassignment":  report,
+	})
+}
+
+// RenameTeam обрабатывает POST /team/rename
+func (h *TeamHandler) RenameTeam(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName    string `json:"team_name"`
+		NewTeamName string `json:"new_team_name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" || req.NewTeamName == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "team_name and new_team_name are required")
+		return
+	}
+
+	team, err := h.service.RenameTeam(r.Context(), req.TeamName, req.NewTeamName)
+	if err != nil {
+		writeMembershipError(w, err, "failed to rename team")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"team": team,
+	})
+}
+
+// DeactivateTeam обрабатывает POST /team/deactivate
+func (h *TeamHandler) DeactivateTeam(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName string `json:"team_name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "team_name is required")
+		return
+	}
+
+	ctx := actorContext(r)
+	report, err := h.service.DeactivateTeam(ctx, req.TeamName)
+	if err != nil {
+		writeMembershipError(w, err, "failed to deactivate team")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"team_name":    req.TeamName,
+		"reassignment": report,
+	})
+}
+
+// DeactivateMembers обрабатывает POST /team/deactivateMembers
+func (h *TeamHandler) DeactivateMembers(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName string `json:"team_name"`
+		// UserIDs - деактивируемые участники, по умолчанию вся команда
+		UserIDs []string `json:"user_ids"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "team_name is required")
+		return
+	}
+
+	seen := make(map[string]bool, len(req.UserIDs))
+	for _, userID := range req.UserIDs {
+		if userID == "" {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "user_ids must not contain empty values")
+			return
+		}
+		if seen[userID] {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "duplicate user_id in user_ids")
+			return
+		}
+		seen[userID] = true
+	}
+
+	ctx := actorContext(r)
+	deactivated, report, err := h.service.DeactivateMembers(ctx, req.TeamName, req.UserIDs)
+	if err != nil {
+		writeMembershipError(w, err, "failed to deactivate team members")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"team_name":    req.TeamName,
+		"deactivated":  deactivated,
+		"reassignment": report,
+	})
+}
+
+// writeMembershipError отвечает ошибкой изменения состава команды
+func writeMembershipError(w http.ResponseWriter, err error, failMessage string) {
+	switch {
+	case errors.Is(err, service.ErrTeamNotFound):
+		response.Error(w, http.StatusNotFound, models.ErrNotFound, "team not found")
+	case errors.Is(err, service.ErrUserNotFound):
+		response.Error(w, http.StatusNotFound, models.ErrNotFound, "user not found")
+	case errors.Is(err, service.ErrNotTeamMember):
+		response.Error(w, http.StatusNotFound, models.ErrNotFound, "user is not a member of the team")
+	case errors.Is(err, service.ErrMemberOfAnotherTeam):
+		response.Error(w, http.StatusConflict, models.ErrMemberOfAnotherTeam, "user is a member of another team, use /team/moveMember")
+	case errors.Is(err, service.ErrTeamExists):
+		response.Error(w, http.StatusConflict, models.ErrTeamExists, "team_name already exists")
+	case errors.Is(err, service.ErrTeamConfigured):
+		response.Error(w, http.StatusConflict, models.ErrTeamConfigured, "team has a policy or strategy settings, update the configuration first")
+	default:
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, failMessage)
+	}
+}
@@ -0,0 +1,145 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/handlers"
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/service"
+)
+
+// fakeMembershipService возвращает заданную ошибку из операций с составом команды
+type fakeMembershipService struct {
+	service.TeamService
+	err error
+}
+
+func (s *fakeMembershipService) CreateTeam(context.Context, *models.Team) error {
+	return s.err
+}
+
+func (s *fakeMembershipService) AddMember(context.Context, string, models.TeamMember) (*models.Team, error) {
+	return nil, s.err
+}
+
+func (s *fakeMembershipService) MoveMember(context.Context, string, string, string) (*models.ReassignmentReport, error) {
+	return nil, s.err
+}
+
+func (s *fakeMembershipService) RenameTeam(context.Context, string, string) (*models.Team, error) {
+	return nil, s.err
+}
+
+func (s *fakeMembershipService) DeactivateMembers(context.Context, string, []string) ([]*models.User, *models.ReassignmentReport, error) {
+	return nil, nil, s.err
+}
+
+func TestTeamMembershipErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    func(h *handlers.TeamHandler) http.HandlerFunc
+		body       string
+		err        error
+		wantStatus int
+		wantCode   models.ErrorCode
+	}{
+		{
+			// Участник другой команды не переводится молча при создании команды
+			name:       "create team with member of another team",
+			handler:    func(h *handlers.TeamHandler) http.HandlerFunc { return h.CreateTeam },
+			body:       `{"team_name":"backend","members":[{"user_id":"u1","username":"Alice","is_active":true}]}`,
+			err:        service.ErrMemberOfAnotherTeam,
+			wantStatus: http.StatusConflict,
+			wantCode:   models.ErrMemberOfAnotherTeam,
+		},
+		{
+			name:       "add member of another team",
+			handler:    func(h *handlers.TeamHandler) http.HandlerFunc { return h.AddMember },
+			body:       `{"team_name":"backend","member":{"user_id":"u1","username":"Alice","is_active":true}}`,
+			err:        service.ErrMemberOfAnotherTeam,
+			wantStatus: http.StatusConflict,
+			wantCode:   models.ErrMemberOfAnotherTeam,
+		},
+		{
+			name:       "move member of another team",
+			handler:    func(h *handlers.TeamHandler) http.HandlerFunc { return h.MoveMember },
+			body:       `{"team_name":"backend","user_id":"u1","new_team_name":"frontend"}`,
+			err:        service.ErrNotTeamMember,
+			wantStatus: http.StatusNotFound,
+			wantCode:   models.ErrNotFound,
+		},
+		{
+			name:       "rename missing team",
+			handler:    func(h *handlers.TeamHandler) http.HandlerFunc { return h.RenameTeam },
+			body:       `{"team_name":"backend","new_team_name":"platform"}`,
+			err:        service.ErrTeamNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   models.ErrNotFound,
+		},
+		{
+			name:       "rename to taken name",
+			handler:    func(h *handlers.TeamHandler) http.HandlerFunc { return h.RenameTeam },
+			body:       `{"team_name":"backend","new_team_name":"frontend"}`,
+			err:        service.ErrTeamExists,
+			wantStatus: http.StatusConflict,
+			wantCode:   models.ErrTeamExists,
+		},
+		{
+			name:       "rename configured team",
+			handler:    func(h *handlers.TeamHandler) http.HandlerFunc { return h.RenameTeam },
+			body:       `{"team_name":"backend","new_team_name":"platform"}`,
+			err:        service.ErrTeamConfigured,
+			wantStatus: http.StatusConflict,
+			wantCode:   models.ErrTeamConfigured,
+		},
+		{
+			// Ошибка БД не выдается за отсутствие команды
+			name:       "rename database failure",
+			handler:    func(h *handlers.TeamHandler) http.HandlerFunc { return h.RenameTeam },
+			body:       `{"team_name":"backend","new_team_name":"platform"}`,
+			err:        errors.New("connection reset"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   models.ErrInternal,
+		},
+		{
+			name:       "deactivate non-member",
+			handler:    func(h *handlers.TeamHandler) http.HandlerFunc { return h.DeactivateMembers },
+			body:       `{"team_name":"backend","user_ids":["u1"]}`,
+			err:        service.ErrNotTeamMember,
+			wantStatus: http.StatusNotFound,
+			wantCode:   models.ErrNotFound,
+		},
+		{
+			name:       "deactivate duplicate user ids",
+			handler:    func(h *handlers.TeamHandler) http.HandlerFunc { return h.DeactivateMembers },
+			body:       `{"team_name":"backend","user_ids":["u1","u1"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   models.ErrBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/team", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			tt.handler(handlers.NewTeamHandler(&fakeMembershipService{err: tt.err}))(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var body models.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if body.Error.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", body.Error.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -25,6 +25,8 @@ func (h *UserHandler) SetIsActive(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		UserID   string `json:"user_id"`
 		IsActive bool   `json:"is_active"`
+		// ReassignOpenReviews при деактивации переназначает открытые ревью пользователя
+		ReassignOpenReviews bool `json:"reassign_open_reviews"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -37,7 +39,30 @@ func (h *UserHandler) SetIsActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := r.Context()
+	if req.IsActive && req.ReassignOpenReviews {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "reassign_open_reviews requires is_active=false")
+		return
+	}
+
+	ctx := actorContext(r)
+	if req.ReassignOpenReviews {
+		user, report, err := h.service.DeactivateUser(ctx, req.UserID)
+		if err != nil {
+			if errors.Is(err, service.ErrUserNotFound) {
+				response.Error(w, http.StatusNotFound, models.ErrNotFound, "user not found")
+				return
+			}
+			response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to deactivate user")
+			return
+		}
+
+		response.JSON(w, http.StatusOK, map[string]interface{}{
+			"user":         user,
+			"reassignment": report,
+		})
+		return
+	}
+
 	user, err := h.service.SetUserActive(ctx, req.UserID, req.IsActive)
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
@@ -77,3 +102,180 @@ func (h *UserHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
 		"pull_requests": prs,
 	})
 }
+
+// GetAvailability обрабатывает GET /users/getAvailability?user_id=...
+func (h *UserHandler) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "user_id query parameter is required")
+		return
+	}
+
+	ctx := r.Context()
+	periods, err := h.service.GetAbsences(ctx, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "user not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to get availability")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"user_id":  userID,
+		"absences": periods,
+	})
+}
+
+// SetAvailability обрабатывает POST /users/setAvailability
+func (h *UserHandler) SetAvailability(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string `json:"user_id"`
+		// Absences заменяют текущие и будущие периоды отсутствия пользователя
+		Absences []models.AbsencePeriod `json:"absences"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.UserID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "user_id is required")
+		return
+	}
+
+	ctx := r.Context()
+	periods, err := h.service.SetAbsences(ctx, req.UserID, req.Absences)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAbsence) {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "each absence must end after it starts and in the future, at most 50 absences")
+			return
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "user not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to set availability")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"user_id":  req.UserID,
+		"absences": periods,
+	})
+}
+
+// SetWorkingHours обрабатывает POST /users/setWorkingHours
+func (h *UserHandler) SetWorkingHours(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID    string `json:"user_id"`
+		Timezone  string `json:"timezone"`
+		WorkStart string `json:"work_start"`
+		WorkEnd   string `json:"work_end"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.UserID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "user_id is required")
+		return
+	}
+
+	ctx := r.Context()
+	user, err := h.service.SetWorkingHours(ctx, req.UserID, req.Timezone, req.WorkStart, req.WorkEnd)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWorkingHours) {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "expected IANA timezone and distinct work_start, work_end in HH:MM, hours require timezone")
+			return
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "user not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to set working hours")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"user": user,
+	})
+}
+
+// SetSkills обрабатывает POST /users/setSkills
+func (h *UserHandler) SetSkills(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string   `json:"user_id"`
+		Skills []string `json:"skills"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.UserID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "user_id is required")
+		return
+	}
+
+	ctx := r.Context()
+	user, err := h.service.SetSkills(ctx, req.UserID, req.Skills)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTags) {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, invalidTagsMessage)
+			return
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "user not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to set skills")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"user": user,
+	})
+}
+
+// SetSeniority обрабатывает POST /users/setSeniority
+func (h *UserHandler) SetSeniority(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID    string           `json:"user_id"`
+		Seniority models.Seniority `json:"seniority"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.UserID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "user_id is required")
+		return
+	}
+
+	ctx := r.Context()
+	user, err := h.service.SetSeniority(ctx, req.UserID, req.Seniority)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSeniority) {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "seniority must be one of JUNIOR, MIDDLE, SENIOR or empty")
+			return
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "user not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to set seniority")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"user": user,
+	})
+}
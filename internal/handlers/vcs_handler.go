@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/response"
+	"github.com/zazaza5818/pr-reviewer-service/internal/service"
+)
+
+// maxWebhookBodySize ограничивает размер тела вебхука
+const maxWebhookBodySize = 1 << 20
+
+// VCSHandler обрабатывает вебхуки систем контроля версий и привязку логинов
+type VCSHandler struct {
+	service      service.VCSService
+	githubSecret []byte
+	gitlabToken  []byte
+}
+
+// NewVCSHandler создает новый обработчик интеграции с системами контроля версий.
+// Пустой секрет отключает прием вебхуков соответствующего провайдера
+func NewVCSHandler(service service.VCSService, githubSecret, gitlabToken string) *VCSHandler {
+	return &VCSHandler{
+		service:      service,
+		githubSecret: []byte(githubSecret),
+		gitlabToken:  []byte(gitlabToken),
+	}
+}
+
+// LinkIdentity обрабатывает POST /vcs/identities
+func (h *VCSHandler) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	var req models.VCSIdentity
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Provider != models.ProviderGitHub && req.Provider != models.ProviderGitLab {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "provider must be github or gitlab")
+		return
+	}
+
+	if req.Login == "" || req.UserID == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "login and user_id are required")
+		return
+	}
+
+	if err := h.service.LinkIdentity(r.Context(), &req); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "user not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to link identity")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"identity": req,
+	})
+}
+
+// handleEvent передает событие сервису и пишет ответ провайдеру.
+// Ошибки, которые не исправятся повтором доставки, возвращаются как 4xx,
+// чтобы провайдер не повторял их бесконечно
+func (h *VCSHandler) handleEvent(w http.ResponseWriter, r *http.Request, event *models.VCSPullRequestEvent) {
+	result, err := h.service.HandlePullRequestEvent(r.Context(), event)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownIdentity) {
+			response.Error(w, http.StatusUnprocessableEntity, models.ErrUnknownIdentity, "author login is not linked to any user")
+			return
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "author not found")
+			return
+		}
+		if errors.Is(err, service.ErrPRMerged) {
+			response.Error(w, http.StatusConflict, models.ErrPRMerged, "cannot modify merged pull request")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidStatus) {
+			response.Error(w, http.StatusConflict, models.ErrInvalidStatus, "transition is not allowed in current status")
+			return
+		}
+		if errors.Is(err, service.ErrCapacityExhausted) {
+			response.Error(w, http.StatusConflict, models.ErrCapacityExhausted, "all candidates reached their open review limit")
+			return
+		}
+		if errors.Is(err, service.ErrNotEnoughReviewers) {
+			response.Error(w, http.StatusConflict, models.ErrNotEnoughReviewers, "not enough candidates to satisfy team min_reviewers")
+			return
+		}
+		if errors.Is(err, service.ErrNoSeniorCandidate) {
+			response.Error(w, http.StatusConflict, models.ErrNoSeniorCandidate, noSeniorCandidateMessage)
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to process webhook")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/response"
+	"github.com/zazaza5818/pr-reviewer-service/internal/service"
+)
+
+// WebhookHandler обрабатывает запросы к подпискам на исходящие вебхуки
+type WebhookHandler struct {
+	service service.WebhookService
+}
+
+// NewWebhookHandler создает новый обработчик подписок на исходящие вебхуки
+func NewWebhookHandler(service service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// CreateSubscription обрабатывает POST /webhooks/subscriptions
+func (h *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL        string                    `json:"url"`
+		Secret     string                    `json:"secret"`
+		EventTypes []models.WebhookEventType `json:"event_types"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.URL == "" || req.Secret == "" {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "url and secret are required")
+		return
+	}
+
+	sub, err := h.service.CreateSubscription(r.Context(), &models.WebhookSubscription{
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSubscription) {
+			response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "url must be http(s) and event_types must be known and unique")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to create subscription")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, map[string]interface{}{
+		"subscription": sub,
+	})
+}
+
+// ListSubscriptions обрабатывает GET /webhooks/subscriptions
+func (h *WebhookHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.ListSubscriptions(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to list subscriptions")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"subscriptions": subs,
+	})
+}
+
+// DeleteSubscription обрабатывает POST /webhooks/subscriptions/delete
+func (h *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "invalid request body")
+		return
+	}
+
+	if req.ID <= 0 {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "id is required")
+		return
+	}
+
+	if err := h.service.DeleteSubscription(r.Context(), req.ID); err != nil {
+		if errors.Is(err, service.ErrSubscriptionNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "subscription not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to delete subscription")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"id": req.ID,
+	})
+}
+
+// GetDeliveries обрабатывает GET /webhooks/deliveries?subscription_id=...
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, err := strconv.ParseInt(r.URL.Query().Get("subscription_id"), 10, 64)
+	if err != nil || subscriptionID <= 0 {
+		response.Error(w, http.StatusBadRequest, models.ErrBadRequest, "subscription_id query parameter is required")
+		return
+	}
+
+	deliveries, err := h.service.GetDeliveries(r.Context(), subscriptionID)
+	if err != nil {
+		if errors.Is(err, service.ErrSubscriptionNotFound) {
+			response.Error(w, http.StatusNotFound, models.ErrNotFound, "subscription not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, models.ErrInternal, "failed to get deliveries")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"subscription_id": subscriptionID,
+		"deliveries":      deliveries,
+	})
+}
@@ -6,15 +6,23 @@ type ErrorCode string
 
 // Коды ошибок API
 const (
-	ErrTeamExists   ErrorCode = "TEAM_EXISTS"
-	ErrPRExists     ErrorCode = "PR_EXISTS"
-	ErrPRMerged     ErrorCode = "PR_MERGED"
-	ErrNotAssigned  ErrorCode = "NOT_ASSIGNED"
-	ErrNoCandidate  ErrorCode = "NO_CANDIDATE"
-	ErrNotFound     ErrorCode = "NOT_FOUND"
-	ErrBadRequest   ErrorCode = "BAD_REQUEST"
-	ErrInternal     ErrorCode = "INTERNAL_ERROR"
-	ErrUnauthorized ErrorCode = "UNAUTHORIZED"
+	ErrTeamExists          ErrorCode = "TEAM_EXISTS"
+	ErrPRExists            ErrorCode = "PR_EXISTS"
+	ErrPRMerged            ErrorCode = "PR_MERGED"
+	ErrInvalidStatus       ErrorCode = "INVALID_STATUS"
+	ErrApprovalsRequired   ErrorCode = "APPROVALS_REQUIRED"
+	ErrNotAssigned         ErrorCode = "NOT_ASSIGNED"
+	ErrNoCandidate         ErrorCode = "NO_CANDIDATE"
+	ErrCapacityExhausted   ErrorCode = "CAPACITY_EXHAUSTED"
+	ErrNotEnoughReviewers  ErrorCode = "NOT_ENOUGH_REVIEWERS"
+	ErrNoSeniorCandidate   ErrorCode = "NO_SENIOR_CANDIDATE"
+	ErrUnknownIdentity     ErrorCode = "UNKNOWN_IDENTITY"
+	ErrMemberOfAnotherTeam ErrorCode = "MEMBER_OF_ANOTHER_TEAM"
+	ErrTeamConfigured      ErrorCode = "TEAM_CONFIGURED"
+	ErrNotFound            ErrorCode = "NOT_FOUND"
+	ErrBadRequest          ErrorCode = "BAD_REQUEST"
+	ErrInternal            ErrorCode = "INTERNAL_ERROR"
+	ErrUnauthorized        ErrorCode = "UNAUTHORIZED"
 )
 
 // ErrorDetail представляет детали ошибки
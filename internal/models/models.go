@@ -4,12 +4,32 @@ import "time"
 
 // User представляет пользователя системы
 type User struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	TeamName       string `json:"team_name"`
+	IsActive       bool   `json:"is_active"`
+	MaxOpenReviews *int   `json:"max_open_reviews,omitempty"`
+	// Timezone - часовой пояс IANA, например Europe/Moscow
+	Timezone string `json:"timezone,omitempty"`
+	// WorkStart и WorkEnd - рабочие часы в часовом поясе пользователя, HH:MM
+	WorkStart string `json:"work_start,omitempty"`
+	WorkEnd   string `json:"work_end,omitempty"`
+	// Skills - навыки пользователя, сопоставляемые с тегами PR
+	Skills []string `json:"skills,omitempty"`
+	// Seniority - уровень пользователя, пустой, если не задан
+	Seniority Seniority `json:"seniority,omitempty"`
 }
 
+// Seniority представляет уровень пользователя
+type Seniority string
+
+// Константы уровней пользователя по возрастанию
+const (
+	SeniorityJunior Seniority = "JUNIOR"
+	SeniorityMiddle Seniority = "MIDDLE"
+	SenioritySenior Seniority = "SENIOR"
+)
+
 // Team представляет команду с участниками
 type Team struct {
 	TeamName string       `json:"team_name"`
@@ -18,9 +38,65 @@ type Team struct {
 
 // TeamMember представляет участника команды
 type TeamMember struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	IsActive       bool   `json:"is_active"`
+	MaxOpenReviews *int   `json:"max_open_reviews,omitempty"`
+}
+
+// Значения настроек команды по умолчанию
+const (
+	DefaultMinReviewers = 0
+	DefaultMaxReviewers = 2
+)
+
+// TeamSettings представляет настройки назначения ревьюверов команды
+type TeamSettings struct {
+	TeamName          string `json:"team_name"`
+	MinReviewers      int    `json:"min_reviewers"`
+	MaxReviewers      int    `json:"max_reviewers"`
+	RequiredApprovals int    `json:"required_approvals"`
+	// PreferWorkingHours - сначала выбирать кандидатов, у которых сейчас рабочее время
+	PreferWorkingHours bool `json:"prefer_working_hours"`
+	// RequiredSeniority и RequiredSeniorityCount - среди ревьюверов должно быть
+	// не меньше RequiredSeniorityCount пользователей уровня RequiredSeniority или выше
+	RequiredSeniority      Seniority `json:"required_seniority,omitempty"`
+	RequiredSeniorityCount int       `json:"required_seniority_count"`
+}
+
+// TeamSettingsUpdate - изменение настроек команды. Незаданные поля
+// сохраняют текущие значения
+type TeamSettingsUpdate struct {
+	TeamName               string     `json:"team_name"`
+	MinReviewers           *int       `json:"min_reviewers,omitempty"`
+	MaxReviewers           *int       `json:"max_reviewers,omitempty"`
+	RequiredApprovals      *int       `json:"required_approvals,omitempty"`
+	PreferWorkingHours     *bool      `json:"prefer_working_hours,omitempty"`
+	RequiredSeniority      *Seniority `json:"required_seniority,omitempty"`
+	RequiredSeniorityCount *int       `json:"required_seniority_count,omitempty"`
+}
+
+// Apply возвращает settings с изменениями из update
+func (u *TeamSettingsUpdate) Apply(settings TeamSettings) TeamSettings {
+	if u.MinReviewers != nil {
+		settings.MinReviewers = *u.MinReviewers
+	}
+	if u.MaxReviewers != nil {
+		settings.MaxReviewers = *u.MaxReviewers
+	}
+	if u.RequiredApprovals != nil {
+		settings.RequiredApprovals = *u.RequiredApprovals
+	}
+	if u.PreferWorkingHours != nil {
+		settings.PreferWorkingHours = *u.PreferWorkingHours
+	}
+	if u.RequiredSeniority != nil {
+		settings.RequiredSeniority = *u.RequiredSeniority
+	}
+	if u.RequiredSeniorityCount != nil {
+		settings.RequiredSeniorityCount = *u.RequiredSeniorityCount
+	}
+	return settings
 }
 
 // PullRequestStatus представляет статус Pull Request
@@ -28,8 +104,10 @@ type PullRequestStatus string
 
 // Константы статусов Pull Request
 const (
+	StatusDraft  PullRequestStatus = "DRAFT"
 	StatusOpen   PullRequestStatus = "OPEN"
 	StatusMerged PullRequestStatus = "MERGED"
+	StatusClosed PullRequestStatus = "CLOSED"
 )
 
 // PullRequest представляет Pull Request
@@ -41,6 +119,273 @@ type PullRequest struct {
 	AssignedReviewers []string          `json:"assigned_reviewers"`
 	CreatedAt         *time.Time        `json:"createdAt,omitempty"`
 	MergedAt          *time.Time        `json:"mergedAt,omitempty"`
+	ClosedAt          *time.Time        `json:"closedAt,omitempty"`
+	// Tags - навыки, нужные для ревью PR
+	Tags []string `json:"tags,omitempty"`
+	// Selection объясняет выбор ревьюверов; заполняется, когда они только что назначены
+	Selection []ReviewerChoice `json:"selection,omitempty"`
+	// Excluded - кандидаты, исключенные правилами при этом назначении
+	Excluded []RuleExclusion `json:"excluded,omitempty"`
+}
+
+// ReviewerChoice объясняет, почему ревьювер выбран
+type ReviewerChoice struct {
+	ReviewerID string   `json:"reviewer_id"`
+	Reasons    []string `json:"reasons"`
+}
+
+// ReviewDecision представляет решение ревьювера по PR
+type ReviewDecision string
+
+// Константы решений ревьювера
+const (
+	DecisionPending          ReviewDecision = "PENDING"
+	DecisionApproved         ReviewDecision = "APPROVED"
+	DecisionChangesRequested ReviewDecision = "CHANGES_REQUESTED"
+)
+
+// Review представляет решение конкретного ревьювера по PR
+type Review struct {
+	PullRequestID string         `json:"pull_request_id"`
+	ReviewerID    string         `json:"reviewer_id"`
+	Decision      ReviewDecision `json:"review_decision"`
+	DecidedAt     *time.Time     `json:"decided_at,omitempty"`
+}
+
+// AssignmentEventType представляет тип события истории назначений
+type AssignmentEventType string
+
+// Константы типов событий истории назначений
+const (
+	AssignmentEventAssigned   AssignmentEventType = "assigned"
+	AssignmentEventUnassigned AssignmentEventType = "unassigned"
+	AssignmentEventReassigned AssignmentEventType = "reassigned"
+	AssignmentEventMerged     AssignmentEventType = "merged"
+	AssignmentEventClosed     AssignmentEventType = "closed"
+)
+
+// AssignmentEvent представляет запись истории назначений ревьюверов PR
+type AssignmentEvent struct {
+	ID                 int64               `json:"id"`
+	PullRequestID      string              `json:"pull_request_id"`
+	EventType          AssignmentEventType `json:"event_type"`
+	ReviewerID         string              `json:"reviewer_id,omitempty"`
+	PreviousReviewerID string              `json:"previous_reviewer_id,omitempty"`
+	ActorID            string              `json:"actor_id,omitempty"`
+	Reason             string              `json:"reason,omitempty"`
+	CreatedAt          time.Time           `json:"created_at"`
+}
+
+// VCSProvider представляет внешнюю систему контроля версий
+type VCSProvider string
+
+// Поддерживаемые системы контроля версий
+const (
+	ProviderGitHub VCSProvider = "github"
+	ProviderGitLab VCSProvider = "gitlab"
+)
+
+// VCSAction представляет действие над PR во внешней системе
+type VCSAction string
+
+// Действия над PR, которые сервис умеет обрабатывать
+const (
+	VCSActionOpened   VCSAction = "opened"
+	VCSActionClosed   VCSAction = "closed"
+	VCSActionMerged   VCSAction = "merged"
+	VCSActionReopened VCSAction = "reopened"
+	VCSActionReady    VCSAction = "ready_for_review"
+)
+
+// VCSIdentity связывает логин во внешней системе с пользователем сервиса
+type VCSIdentity struct {
+	Provider VCSProvider `json:"provider"`
+	Login    string      `json:"login"`
+	UserID   string      `json:"user_id"`
+}
+
+// VCSPullRequestEvent - событие PR из внешней системы, приведенное к общему виду
+type VCSPullRequestEvent struct {
+	Provider   VCSProvider
+	DeliveryID string
+	Action     VCSAction
+	// PullRequestID - идентификатор PR в сервисе, например "org/repo#42"
+	PullRequestID   string
+	PullRequestName string
+	AuthorLogin     string
+	SenderLogin     string
+	Draft           bool
+}
+
+// WebhookEventType представляет тип события для исходящих вебхуков
+type WebhookEventType string
+
+// События, на которые можно подписаться
+const (
+	EventPRCreated          WebhookEventType = "pr.created"
+	EventReviewerAssigned   WebhookEventType = "reviewer.assigned"
+	EventReviewerReassigned WebhookEventType = "reviewer.reassigned"
+	EventPRMerged           WebhookEventType = "pr.merged"
+	EventPRClosed           WebhookEventType = "pr.closed"
+	EventUserDeactivated    WebhookEventType = "user.deactivated"
+)
+
+// WebhookEvent - событие, отправляемое подписчикам исходящих вебхуков
+type WebhookEvent struct {
+	ID         string           `json:"id"`
+	Type       WebhookEventType `json:"type"`
+	OccurredAt time.Time        `json:"occurred_at"`
+	Data       interface{}      `json:"data"`
+}
+
+// OutboxEntry - событие в outbox, ожидающее публикации
+type OutboxEntry struct {
+	ID       int64
+	Event    WebhookEvent
+	Attempts int
+}
+
+// WebhookSubscription представляет подписку на исходящие вебхуки
+type WebhookSubscription struct {
+	ID  int64  `json:"id"`
+	URL string `json:"url"`
+	// Secret используется для подписи тела запроса и не возвращается в API
+	Secret string `json:"-"`
+	// EventTypes - фильтр событий, пустой список означает все события
+	EventTypes []WebhookEventType `json:"event_types"`
+	IsActive   bool               `json:"is_active"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// WebhookDelivery представляет одну попытку доставки события подписчику
+type WebhookDelivery struct {
+	ID             int64            `json:"id"`
+	SubscriptionID int64            `json:"subscription_id"`
+	EventID        string           `json:"event_id"`
+	EventType      WebhookEventType `json:"event_type"`
+	Attempt        int              `json:"attempt"`
+	StatusCode     *int             `json:"status_code,omitempty"`
+	Error          string           `json:"error,omitempty"`
+	Succeeded      bool             `json:"succeeded"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+// WebhookJob - задание доставки события одному подписчику
+type WebhookJob struct {
+	ID             int64
+	SubscriptionID int64
+	URL            string
+	Secret         string
+	EventID        string
+	EventType      WebhookEventType
+	Body           []byte
+	// Attempts - число уже выполненных попыток
+	Attempts int
+}
+
+// AbsencePeriod - период отсутствия пользователя. Пока он длится,
+// пользователь не выбирается ревьювером
+type AbsencePeriod struct {
+	ID       int64     `json:"id"`
+	UserID   string    `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// OwnershipRule - правило владения кодом в стиле CODEOWNERS. Для каждого
+// пути действует последнее подходящее правило
+type OwnershipRule struct {
+	ID int64 `json:"id"`
+	// Pattern - glob-шаблон пути: * и ? внутри сегмента, ** - любое число
+	// сегментов, ведущий / привязывает к корню репозитория
+	Pattern string   `json:"pattern"`
+	Users   []string `json:"users"`
+	Teams   []string `json:"teams"`
+}
+
+// ReviewerRuleKind представляет тип правила назначения ревьюверов
+type ReviewerRuleKind string
+
+// Константы типов правил назначения ревьюверов
+const (
+	// RuleExclude - UserID никогда не ревьюит PR автора TargetID
+	RuleExclude ReviewerRuleKind = "EXCLUDE"
+	// RulePair - UserID назначается только вместе с наставником TargetID
+	RulePair ReviewerRuleKind = "PAIR"
+	// RulePolicy - исключение из exclude_users политики команды; такие
+	// правила не хранятся в БД и не имеют ID
+	RulePolicy ReviewerRuleKind = "POLICY"
+)
+
+// ReviewerRule - правило назначения ревьюверов, которое задает администратор
+type ReviewerRule struct {
+	ID       int64            `json:"id"`
+	Kind     ReviewerRuleKind `json:"kind"`
+	UserID   string           `json:"user_id"`
+	TargetID string           `json:"target_id"`
+	Comment  string           `json:"comment,omitempty"`
+}
+
+// RuleExclusion - кандидат, не назначенный из-за правила
+type RuleExclusion struct {
+	RuleID int64            `json:"rule_id,omitempty"`
+	Kind   ReviewerRuleKind `json:"kind"`
+	UserID string           `json:"user_id"`
+	Reason string           `json:"reason"`
+}
+
+// TeamPolicy - декларативная политика назначения ревьюверов команды,
+// загружаемая из JSON-файла. Незаданные поля берутся из настроек команды
+// и конфигурации сервиса
+type TeamPolicy struct {
+	Team              string `json:"team"`
+	MinReviewers      *int   `json:"min_reviewers,omitempty"`
+	MaxReviewers      *int   `json:"max_reviewers,omitempty"`
+	RequiredApprovals *int   `json:"required_approvals,omitempty"`
+	// FallbackTeams заменяет резервные команды; пустой список - без резервных
+	FallbackTeams []string `json:"fallback_teams,omitempty"`
+	// ExcludeUsers - пользователи, которые не назначаются на PR команды
+	ExcludeUsers       []string              `json:"exclude_users,omitempty"`
+	RequiredSeniority  *SeniorityRequirement `json:"required_seniority,omitempty"`
+	Strategy           string                `json:"strategy,omitempty"`
+	PreferWorkingHours *bool                 `json:"prefer_working_hours,omitempty"`
+}
+
+// SeniorityRequirement - сколько ревьюверов должны иметь уровень Level или выше
+type SeniorityRequirement struct {
+	Level Seniority `json:"level"`
+	Count int       `json:"count"`
+}
+
+// PolicyValidation - результат проверки политики команды
+type PolicyValidation struct {
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+	// Settings - настройки команды с учетом политики, если она корректна
+	Settings      *TeamSettings `json:"settings,omitempty"`
+	FallbackTeams []string      `json:"fallback_teams,omitempty"`
+}
+
+// ReviewAssignment - назначение ревьювера на PR
+type ReviewAssignment struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+}
+
+// ReviewReassignment описывает переназначение открытого ревью ушедшего ревьювера
+type ReviewReassignment struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	// NewReviewerID пуст, если замену найти не удалось
+	NewReviewerID string `json:"new_reviewer_id,omitempty"`
+}
+
+// ReassignmentReport - итог переназначения открытых ревью
+type ReassignmentReport struct {
+	Reassigned []ReviewReassignment `json:"reassigned"`
+	// NoCandidate - ревью, для которых не нашлось замены; ревьювер остается назначенным
+	NoCandidate []ReviewReassignment `json:"no_candidate"`
 }
 
 // PullRequestShort представляет краткую информацию о Pull Request
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// assignmentEventRepository реализует AssignmentEventRepository
+type assignmentEventRepository struct {
+	db *sql.DB
+}
+
+// NewAssignmentEventRepository создает новый репозиторий истории назначений
+func NewAssignmentEventRepository(db *sql.DB) AssignmentEventRepository {
+	return &assignmentEventRepository{db: db}
+}
+
+// conn возвращает транзакцию из контекста или подключение к БД
+func (r *assignmentEventRepository) conn(ctx context.Context) dbExecutor {
+	return executor(ctx, r.db)
+}
+
+// Add добавляет событие в историю (в транзакции из контекста, если она есть)
+func (r *assignmentEventRepository) Add(ctx context.Context, event *models.AssignmentEvent) error {
+	query := `
+		INSERT INTO pr_assignment_events
+			(pull_request_id, event_type, reviewer_id, previous_reviewer_id, actor_id, reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.conn(ctx).QueryRowContext(ctx, query,
+		event.PullRequestID,
+		event.EventType,
+		nullString(event.ReviewerID),
+		nullString(event.PreviousReviewerID),
+		nullString(event.ActorID),
+		nullString(event.Reason),
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add assignment event: %w", err)
+	}
+	return nil
+}
+
+// GetByPullRequest возвращает историю назначений PR в хронологическом порядке
+func (r *assignmentEventRepository) GetByPullRequest(ctx context.Context, prID string) ([]*models.AssignmentEvent, error) {
+	query := `
+		SELECT id, pull_request_id, event_type, reviewer_id, previous_reviewer_id, actor_id, reason, created_at
+		FROM pr_assignment_events
+		WHERE pull_request_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, prID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment events: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	events := []*models.AssignmentEvent{}
+	for rows.Next() {
+		var event models.AssignmentEvent
+		var reviewerID, previousReviewerID, actorID, reason sql.NullString
+		if err := rows.Scan(
+			&event.ID,
+			&event.PullRequestID,
+			&event.EventType,
+			&reviewerID,
+			&previousReviewerID,
+			&actorID,
+			&reason,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan assignment event: %w", err)
+		}
+		event.ReviewerID = reviewerID.String
+		event.PreviousReviewerID = previousReviewerID.String
+		event.ActorID = actorID.String
+		event.Reason = reason.String
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return events, nil
+}
+
+// nullString превращает пустую строку в NULL
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// availabilityRepository реализует AvailabilityRepository
+type availabilityRepository struct {
+	db *sql.DB
+}
+
+// NewAvailabilityRepository создает новый репозиторий периодов отсутствия
+func NewAvailabilityRepository(db *sql.DB) AvailabilityRepository {
+	return &availabilityRepository{db: db}
+}
+
+// conn возвращает транзакцию из контекста или подключение к БД
+func (r *availabilityRepository) conn(ctx context.Context) dbExecutor {
+	return executor(ctx, r.db)
+}
+
+// ReplaceUpcoming заменяет текущие и будущие периоды отсутствия пользователя.
+// Завершившиеся периоды остаются как история. Если ревью пользователя уже
+// переназначены из-за начавшегося периода, новые начавшиеся периоды
+// сохраняют эту отметку, и повторно ревью не переназначаются
+func (r *availabilityRepository) ReplaceUpcoming(ctx context.Context, userID string, periods []models.AbsencePeriod) error {
+	return withTx(ctx, r.db, func(ctx context.Context) error {
+		var reassignedAt sql.NullTime
+		err := r.conn(ctx).QueryRowContext(ctx, `
+			WITH deleted AS (
+				DELETE FROM user_availability
+				WHERE user_id = $1 AND ends_at > CURRENT_TIMESTAMP
+				RETURNING starts_at, reassigned_at
+			)
+			SELECT MAX(reassigned_at) FROM deleted WHERE starts_at <= CURRENT_TIMESTAMP
+		`, userID).Scan(&reassignedAt)
+		if err != nil {
+			return fmt.Errorf("failed to delete absence periods: %w", err)
+		}
+
+		for _, period := range periods {
+			_, err := r.conn(ctx).ExecContext(ctx, `
+				INSERT INTO user_availability (user_id, starts_at, ends_at, reason, reassigned_at)
+				VALUES ($1, $2, $3, $4, CASE WHEN $2 <= CURRENT_TIMESTAMP THEN $5::timestamptz END)
+			`, userID, period.StartsAt, period.EndsAt, period.Reason, reassignedAt)
+			if err != nil {
+				return fmt.Errorf("failed to add absence period: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListUpcoming возвращает текущие и будущие периоды отсутствия пользователя
+func (r *availabilityRepository) ListUpcoming(ctx context.Context, userID string) ([]models.AbsencePeriod, error) {
+	query := `
+		SELECT id, user_id, starts_at, ends_at, reason
+		FROM user_availability
+		WHERE user_id = $1 AND ends_at > CURRENT_TIMESTAMP
+		ORDER BY starts_at
+	`
+	return r.queryPeriods(ctx, query, userID)
+}
+
+// FetchStarted возвращает до limit начавшихся и еще не обработанных периодов,
+// пропуская периоды, повтор которых отложен после ошибки. Строки блокируются
+// до конца транзакции из контекста, поэтому несколько экземпляров сервиса
+// не обрабатывают один период одновременно
+func (r *availabilityRepository) FetchStarted(ctx context.Context, limit int) ([]models.AbsencePeriod, error) {
+	query := `
+		SELECT id, user_id, starts_at, ends_at, reason
+		FROM user_availability
+		WHERE reassigned_at IS NULL
+		  AND starts_at <= CURRENT_TIMESTAMP AND ends_at > CURRENT_TIMESTAMP
+		  AND (retry_at IS NULL OR retry_at <= CURRENT_TIMESTAMP)
+		ORDER BY starts_at, id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+	return r.queryPeriods(ctx, query, limit)
+}
+
+// MarkReassigned отмечает, что открытые ревью пользователя за период переназначены
+func (r *availabilityRepository) MarkReassigned(ctx context.Context, id int64) error {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE user_availability SET reassigned_at = CURRENT_TIMESTAMP WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark absence period: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("absence period not found")
+	}
+
+	return nil
+}
+
+// MarkFailed сохраняет причину ошибки переназначения и откладывает
+// следующую попытку на retryAfter
+func (r *availabilityRepository) MarkFailed(ctx context.Context, id int64, reason string, retryAfter time.Duration) error {
+	query := `
+		UPDATE user_availability
+		SET attempts = attempts + 1, last_error = $2,
+		    retry_at = CURRENT_TIMESTAMP + $3 * INTERVAL '1 microsecond'
+		WHERE id = $1
+	`
+
+	if _, err := r.conn(ctx).ExecContext(ctx, query, id, reason, retryAfter.Microseconds()); err != nil {
+		return fmt.Errorf("failed to mark absence period failed: %w", err)
+	}
+	return nil
+}
+
+// queryPeriods читает периоды отсутствия по запросу
+func (r *availabilityRepository) queryPeriods(ctx context.Context, query string, args ...interface{}) ([]models.AbsencePeriod, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absence periods: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	periods := []models.AbsencePeriod{}
+	for rows.Next() {
+		var period models.AbsencePeriod
+		if err := rows.Scan(&period.ID, &period.UserID, &period.StartsAt, &period.EndsAt, &period.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan absence period: %w", err)
+		}
+		periods = append(periods, period)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return periods, nil
+}
@@ -0,0 +1,113 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/database/dbtest"
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+func TestReplaceUpcomingKeepsReassignedMark(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	teamRepo := repository.NewTeamRepository(db)
+	availabilityRepo := repository.NewAvailabilityRepository(db)
+
+	team := &models.Team{
+		TeamName: "backend",
+		Members:  []models.TeamMember{{UserID: "alice", Username: "Alice", IsActive: true}},
+	}
+	if err := teamRepo.CreateWithMembers(ctx, team); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+
+	now := time.Now()
+	current := models.AbsencePeriod{StartsAt: now.Add(-time.Hour), EndsAt: now.Add(24 * time.Hour), Reason: "vacation"}
+	if err := availabilityRepo.ReplaceUpcoming(ctx, "alice", []models.AbsencePeriod{current}); err != nil {
+		t.Fatalf("failed to set absence periods: %v", err)
+	}
+
+	started, err := availabilityRepo.FetchStarted(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to fetch started periods: %v", err)
+	}
+	if len(started) != 1 {
+		t.Fatalf("got %d started periods, want 1", len(started))
+	}
+	if err := availabilityRepo.MarkReassigned(ctx, started[0].ID); err != nil {
+		t.Fatalf("failed to mark period: %v", err)
+	}
+
+	// Пользователь продлевает текущий отпуск и добавляет будущий
+	current.EndsAt = now.Add(48 * time.Hour)
+	future := models.AbsencePeriod{StartsAt: now.Add(72 * time.Hour), EndsAt: now.Add(96 * time.Hour)}
+	if err := availabilityRepo.ReplaceUpcoming(ctx, "alice", []models.AbsencePeriod{current, future}); err != nil {
+		t.Fatalf("failed to replace absence periods: %v", err)
+	}
+
+	upcoming, err := availabilityRepo.ListUpcoming(ctx, "alice")
+	if err != nil {
+		t.Fatalf("failed to list periods: %v", err)
+	}
+	if len(upcoming) != 2 {
+		t.Fatalf("got %d upcoming periods, want 2", len(upcoming))
+	}
+
+	// Ревью уже переназначены, и начавшийся период не обрабатывается повторно
+	started, err = availabilityRepo.FetchStarted(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to fetch started periods: %v", err)
+	}
+	if len(started) != 0 {
+		t.Errorf("got %d started periods after replace, want 0", len(started))
+	}
+}
+
+func TestFetchStartedSkipsFailedPeriods(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	teamRepo := repository.NewTeamRepository(db)
+	availabilityRepo := repository.NewAvailabilityRepository(db)
+
+	team := &models.Team{
+		TeamName: "backend",
+		Members: []models.TeamMember{
+			{UserID: "alice", Username: "Alice", IsActive: true},
+			{UserID: "bob", Username: "Bob", IsActive: true},
+		},
+	}
+	if err := teamRepo.CreateWithMembers(ctx, team); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+
+	now := time.Now()
+	for i, userID := range []string{"alice", "bob"} {
+		period := models.AbsencePeriod{StartsAt: now.Add(-time.Duration(2-i) * time.Hour), EndsAt: now.Add(time.Hour)}
+		if err := availabilityRepo.ReplaceUpcoming(ctx, userID, []models.AbsencePeriod{period}); err != nil {
+			t.Fatalf("failed to set absence periods: %v", err)
+		}
+	}
+
+	started, err := availabilityRepo.FetchStarted(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to fetch started periods: %v", err)
+	}
+	if len(started) != 1 || started[0].UserID != "alice" {
+		t.Fatalf("started = %+v, want the period of alice first", started)
+	}
+	if err := availabilityRepo.MarkFailed(ctx, started[0].ID, "lock timeout", time.Hour); err != nil {
+		t.Fatalf("failed to mark period failed: %v", err)
+	}
+
+	// Отложенный период не загораживает следующий
+	started, err = availabilityRepo.FetchStarted(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to fetch started periods: %v", err)
+	}
+	if len(started) != 1 || started[0].UserID != "bob" {
+		t.Errorf("started = %+v, want only the period of bob", started)
+	}
+}
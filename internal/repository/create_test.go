@@ -0,0 +1,104 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/database/dbtest"
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// concurrentCreates - число одновременных попыток создать одну и ту же запись
+const concurrentCreates = 16
+
+// createConcurrently одновременно вызывает create из concurrentCreates горутин
+// и проверяет, что успешна ровно одна попытка, а остальные вернули exists
+func createConcurrently(t *testing.T, exists error, create func(ctx context.Context) error) {
+	t.Helper()
+
+	start := make(chan struct{})
+	errs := make(chan error, concurrentCreates)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentCreates; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs <- create(context.Background())
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, exists):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("created %d times, want exactly once", created)
+	}
+}
+
+func TestTeamCreateConcurrent(t *testing.T) {
+	db := dbtest.Open(t)
+	teamRepo := repository.NewTeamRepository(db)
+
+	createConcurrently(t, repository.ErrTeamExists, func(ctx context.Context) error {
+		return teamRepo.Create(ctx, &models.Team{TeamName: "race-team"})
+	})
+}
+
+func TestUserCreateConcurrent(t *testing.T) {
+	db := dbtest.Open(t)
+	teamRepo := repository.NewTeamRepository(db)
+	userRepo := repository.NewUserRepository(db)
+
+	if err := teamRepo.Create(context.Background(), &models.Team{TeamName: "race-team"}); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+
+	createConcurrently(t, repository.ErrUserExists, func(ctx context.Context) error {
+		return userRepo.Create(ctx, &models.User{
+			UserID:   "race-user",
+			Username: "Race",
+			TeamName: "race-team",
+			IsActive: true,
+		})
+	})
+}
+
+func TestPullRequestCreateConcurrent(t *testing.T) {
+	db := dbtest.Open(t)
+	teamRepo := repository.NewTeamRepository(db)
+	prRepo := repository.NewPullRequestRepository(db)
+
+	team := &models.Team{
+		TeamName: "race-team",
+		Members: []models.TeamMember{
+			{UserID: "race-author", Username: "Author", IsActive: true},
+			{UserID: "race-reviewer", Username: "Reviewer", IsActive: true},
+		},
+	}
+	if err := teamRepo.CreateWithMembers(context.Background(), team); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+
+	createConcurrently(t, repository.ErrPRExists, func(ctx context.Context) error {
+		return prRepo.Create(ctx, &models.PullRequest{
+			PullRequestID:     "race-pr",
+			PullRequestName:   "Race",
+			AuthorID:          "race-author",
+			Status:            models.StatusOpen,
+			AssignedReviewers: []string{"race-reviewer"},
+		})
+	})
+}
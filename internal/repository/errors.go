@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Ошибки нарушения уникальности при создании записей
+var (
+	ErrPRExists   = errors.New("pull request already exists")
+	ErrTeamExists = errors.New("team already exists")
+	ErrUserExists = errors.New("user already exists")
+)
+
+// Ошибки, которые сервисы отличают от прочих ошибок БД
+var (
+	ErrPRNotFound          = errors.New("pull request not found")
+	ErrIdentityNotFound    = errors.New("vcs identity not found")
+	ErrTeamNotFound        = errors.New("team not found")
+	ErrMemberOfAnotherTeam = errors.New("user is a member of another team")
+)
+
+// uniqueViolation - код ошибки PostgreSQL при нарушении уникальности
+const uniqueViolation = "23505"
+
+// isUniqueViolation сообщает, что запрос нарушил ограничение уникальности
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
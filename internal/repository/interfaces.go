@@ -3,6 +3,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/zazaza5818/pr-reviewer-service/internal/models"
 )
@@ -10,8 +11,14 @@ import (
 // TeamRepository определяет интерфейс для работы с командами
 type TeamRepository interface {
 	Create(ctx context.Context, team *models.Team) error
+	CreateWithMembers(ctx context.Context, team *models.Team) error
+	Rename(ctx context.Context, oldName, newName string) error
 	Get(ctx context.Context, teamName string) (*models.Team, error)
 	Exists(ctx context.Context, teamName string) (bool, error)
+	GetSettings(ctx context.Context, teamName string) (*models.TeamSettings, error)
+	UpsertSettings(ctx context.Context, settings *models.TeamSettings) error
+	GetFallbackTeams(ctx context.Context, teamName string) ([]string, error)
+	SetFallbackTeams(ctx context.Context, teamName string, fallbackTeams []string) error
 }
 
 // UserRepository определяет интерфейс для работы с пользователями
@@ -21,18 +28,95 @@ type UserRepository interface {
 	Get(ctx context.Context, userID string) (*models.User, error)
 	GetByTeam(ctx context.Context, teamName string) ([]*models.User, error)
 	SetActive(ctx context.Context, userID string, isActive bool) error
+	SetTeam(ctx context.Context, userID, teamName string) error
+	SetWorkingHours(ctx context.Context, userID, timezone, workStart, workEnd string) error
+	SetSkills(ctx context.Context, userID string, skills []string) error
+	SetSeniority(ctx context.Context, userID string, seniority models.Seniority) error
+	DeactivateMany(ctx context.Context, teamName string, userIDs []string) ([]*models.User, error)
 	GetActiveTeammates(ctx context.Context, teamName string, excludeUserID string) ([]*models.User, error)
+	GetAvailableByIDs(ctx context.Context, userIDs []string) ([]*models.User, error)
 }
 
 // PullRequestRepository определяет интерфейс для работы с Pull Request
 type PullRequestRepository interface {
 	Create(ctx context.Context, pr *models.PullRequest) error
 	Get(ctx context.Context, prID string) (*models.PullRequest, error)
+	GetForUpdate(ctx context.Context, prID string) (*models.PullRequest, error)
 	Update(ctx context.Context, pr *models.PullRequest) error
 	Exists(ctx context.Context, prID string) (bool, error)
 	GetByReviewer(ctx context.Context, reviewerID string) ([]*models.PullRequestShort, error)
+	GetOpenAssignments(ctx context.Context, reviewerIDs []string) ([]models.ReviewAssignment, error)
+	SetChangedFiles(ctx context.Context, prID string, paths []string) error
+	GetChangedFiles(ctx context.Context, prID string) ([]string, error)
+	SetTags(ctx context.Context, prID string, tags []string) error
 	AssignReviewer(ctx context.Context, prID, reviewerID string) error
 	RemoveReviewer(ctx context.Context, prID, reviewerID string) error
 	GetReviewers(ctx context.Context, prID string) ([]string, error)
 	IsReviewerAssigned(ctx context.Context, prID, reviewerID string) (bool, error)
+	CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error)
+	SetReviewDecision(ctx context.Context, prID, reviewerID string, decision models.ReviewDecision) error
+	GetReviews(ctx context.Context, prID string) ([]models.Review, error)
+}
+
+// AssignmentEventRepository определяет интерфейс для истории назначений ревьюверов
+type AssignmentEventRepository interface {
+	Add(ctx context.Context, event *models.AssignmentEvent) error
+	GetByPullRequest(ctx context.Context, prID string) ([]*models.AssignmentEvent, error)
+}
+
+// VCSRepository определяет интерфейс для сопоставления учетных записей
+// внешних систем и защиты от повторной обработки вебхуков
+type VCSRepository interface {
+	UpsertIdentity(ctx context.Context, identity *models.VCSIdentity) error
+	ResolveUserID(ctx context.Context, provider models.VCSProvider, login string) (string, error)
+	MarkDelivery(ctx context.Context, provider models.VCSProvider, deliveryID string) (bool, error)
+}
+
+// WebhookRepository определяет интерфейс для подписок на исходящие вебхуки
+// и журнала их доставки
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	ListSubscriptionsForEvent(ctx context.Context, eventType models.WebhookEventType) ([]*models.WebhookSubscription, error)
+	AddDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	AddJobs(ctx context.Context, jobs []*models.WebhookJob) error
+	ClaimDueJobs(ctx context.Context, limit int) ([]*models.WebhookJob, error)
+	CompleteJob(ctx context.Context, id int64, reason string) error
+	RescheduleJob(ctx context.Context, id int64, delay time.Duration, reason string) error
+	GetDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*models.WebhookDelivery, error)
+}
+
+// OutboxRepository определяет интерфейс для outbox доменных событий
+type OutboxRepository interface {
+	Add(ctx context.Context, event *models.WebhookEvent) error
+	FetchPending(ctx context.Context, limit, maxAttempts int) ([]*models.OutboxEntry, error)
+	MarkDelivered(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, reason string) error
+}
+
+// AvailabilityRepository определяет интерфейс для периодов отсутствия пользователей
+type AvailabilityRepository interface {
+	ReplaceUpcoming(ctx context.Context, userID string, periods []models.AbsencePeriod) error
+	ListUpcoming(ctx context.Context, userID string) ([]models.AbsencePeriod, error)
+	FetchStarted(ctx context.Context, limit int) ([]models.AbsencePeriod, error)
+	MarkReassigned(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, reason string, retryAfter time.Duration) error
+}
+
+// OwnershipRepository определяет интерфейс для правил владения кодом
+type OwnershipRepository interface {
+	CreateRule(ctx context.Context, rule *models.OwnershipRule) error
+	UpdateRule(ctx context.Context, rule *models.OwnershipRule) error
+	DeleteRule(ctx context.Context, id int64) error
+	ListRules(ctx context.Context) ([]*models.OwnershipRule, error)
+}
+
+// RuleRepository определяет интерфейс для правил назначения ревьюверов
+type RuleRepository interface {
+	CreateRule(ctx context.Context, rule *models.ReviewerRule) error
+	UpdateRule(ctx context.Context, rule *models.ReviewerRule) error
+	DeleteRule(ctx context.Context, id int64) error
+	ListRules(ctx context.Context) ([]*models.ReviewerRule, error)
 }
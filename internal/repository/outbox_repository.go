@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// outboxRepository реализует OutboxRepository
+type outboxRepository struct {
+	db *sql.DB
+}
+
+// NewOutboxRepository создает новый репозиторий outbox
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// conn возвращает транзакцию из контекста или подключение к БД
+func (r *outboxRepository) conn(ctx context.Context) dbExecutor {
+	return executor(ctx, r.db)
+}
+
+// Add записывает событие в outbox (в транзакции из контекста, если она есть)
+func (r *outboxRepository) Add(ctx context.Context, event *models.WebhookEvent) error {
+	payload, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox payload: %w", err)
+	}
+
+	query := `
+		INSERT INTO outbox (event_id, event_type, payload, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err = r.conn(ctx).ExecContext(ctx, query, event.ID, event.Type, payload, event.OccurredAt)
+	if err != nil {
+		return fmt.Errorf("failed to add outbox event: %w", err)
+	}
+	return nil
+}
+
+// FetchPending возвращает до limit неопубликованных событий в порядке записи,
+// пропуская события, исчерпавшие maxAttempts. Строки блокируются до конца
+// транзакции из контекста, поэтому несколько экземпляров сервиса
+// не публикуют одно и то же событие одновременно
+func (r *outboxRepository) FetchPending(ctx context.Context, limit, maxAttempts int) ([]*models.OutboxEntry, error) {
+	query := `
+		SELECT id, event_id, event_type, payload, created_at, attempts
+		FROM outbox
+		WHERE delivered_at IS NULL AND attempts < $2
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, limit, maxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch outbox events: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	entries := []*models.OutboxEntry{}
+	for rows.Next() {
+		var entry models.OutboxEntry
+		var payload []byte
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.Event.ID,
+			&entry.Event.Type,
+			&payload,
+			&entry.Event.OccurredAt,
+			&entry.Attempts,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		entry.Event.Data = json.RawMessage(payload)
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return entries, nil
+}
+
+// MarkDelivered отмечает событие опубликованным
+func (r *outboxRepository) MarkDelivered(ctx context.Context, id int64) error {
+	query := `
+		UPDATE outbox
+		SET delivered_at = CURRENT_TIMESTAMP, attempts = attempts + 1, last_error = NULL
+		WHERE id = $1
+	`
+
+	if _, err := r.conn(ctx).ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark outbox event delivered: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed увеличивает счетчик попыток и сохраняет причину ошибки
+func (r *outboxRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = $2
+		WHERE id = $1
+	`
+
+	if _, err := r.conn(ctx).ExecContext(ctx, query, id, reason); err != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", err)
+	}
+	return nil
+}
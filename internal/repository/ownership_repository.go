@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// ownershipRepository реализует OwnershipRepository
+type ownershipRepository struct {
+	db *sql.DB
+}
+
+// NewOwnershipRepository создает новый репозиторий правил владения кодом
+func NewOwnershipRepository(db *sql.DB) OwnershipRepository {
+	return &ownershipRepository{db: db}
+}
+
+// conn возвращает транзакцию из контекста или подключение к БД
+func (r *ownershipRepository) conn(ctx context.Context) dbExecutor {
+	return executor(ctx, r.db)
+}
+
+// CreateRule создает правило и заполняет его ID
+func (r *ownershipRepository) CreateRule(ctx context.Context, rule *models.OwnershipRule) error {
+	query := `
+		INSERT INTO ownership_rules (pattern, owner_users, owner_teams)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, rule.Pattern, pq.Array(rule.Users), pq.Array(rule.Teams)).Scan(&rule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create ownership rule: %w", err)
+	}
+	return nil
+}
+
+// UpdateRule заменяет шаблон и владельцев правила
+func (r *ownershipRepository) UpdateRule(ctx context.Context, rule *models.OwnershipRule) error {
+	query := `
+		UPDATE ownership_rules
+		SET pattern = $1, owner_users = $2, owner_teams = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, rule.Pattern, pq.Array(rule.Users), pq.Array(rule.Teams), rule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update ownership rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("ownership rule not found")
+	}
+
+	return nil
+}
+
+// DeleteRule удаляет правило
+func (r *ownershipRepository) DeleteRule(ctx context.Context, id int64) error {
+	result, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM ownership_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete ownership rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("ownership rule not found")
+	}
+
+	return nil
+}
+
+// ListRules возвращает все правила в порядке применения
+func (r *ownershipRepository) ListRules(ctx context.Context) ([]*models.OwnershipRule, error) {
+	query := `
+		SELECT id, pattern, owner_users, owner_teams
+		FROM ownership_rules
+		ORDER BY id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ownership rules: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	rules := []*models.OwnershipRule{}
+	for rows.Next() {
+		var rule models.OwnershipRule
+		if err := rows.Scan(&rule.ID, &rule.Pattern, pq.Array(&rule.Users), pq.Array(&rule.Teams)); err != nil {
+			return nil, fmt.Errorf("failed to scan ownership rule: %w", err)
+		}
+		rules = append(rules, &rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return rules, nil
+}
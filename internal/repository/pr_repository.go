@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/zazaza5818/pr-reviewer-service/internal/models"
 )
 
@@ -20,37 +21,39 @@ func NewPullRequestRepository(db *sql.DB) PullRequestRepository {
 	return &prRepository{db: db}
 }
 
-// Create создает новый Pull Request
-func (r *prRepository) Create(ctx context.Context, pr *models.PullRequest) error {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
+// conn возвращает транзакцию из контекста или подключение к БД
+func (r *prRepository) conn(ctx context.Context) dbExecutor {
+	return executor(ctx, r.db)
+}
 
-	// Создаем PR
-	query := `
-		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-	`
+// Create создает новый Pull Request. Если PR с таким ID уже есть,
+// возвращает ErrPRExists
+func (r *prRepository) Create(ctx context.Context, pr *models.PullRequest) error {
 	now := time.Now()
-	_, err = tx.ExecContext(ctx, query, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, now)
-	if err != nil {
-		return fmt.Errorf("failed to create pull request: %w", err)
-	}
-
-	// Назначаем ревьюверов
-	for _, reviewerID := range pr.AssignedReviewers {
-		err = r.assignReviewerTx(ctx, tx, pr.PullRequestID, reviewerID)
+	err := withTx(ctx, r.db, func(ctx context.Context) error {
+		// Создаем PR
+		query := `
+			INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`
+		_, err := r.conn(ctx).ExecContext(ctx, query, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, now)
 		if err != nil {
-			return err
+			if isUniqueViolation(err) {
+				return ErrPRExists
+			}
+			return fmt.Errorf("failed to create pull request: %w", err)
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		// Назначаем ревьюверов
+		for _, reviewerID := range pr.AssignedReviewers {
+			if err := r.AssignReviewer(ctx, pr.PullRequestID, reviewerID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	pr.CreatedAt = &now
@@ -59,28 +62,46 @@ func (r *prRepository) Create(ctx context.Context, pr *models.PullRequest) error
 
 // Get возвращает Pull Request по ID
 func (r *prRepository) Get(ctx context.Context, prID string) (*models.PullRequest, error) {
+	return r.get(ctx, prID, false)
+}
+
+// GetForUpdate возвращает Pull Request по ID, блокируя его строку до конца
+// транзакции из контекста. Параллельные изменения того же PR ждут ее завершения
+func (r *prRepository) GetForUpdate(ctx context.Context, prID string) (*models.PullRequest, error) {
+	return r.get(ctx, prID, true)
+}
+
+// get читает Pull Request вместе с ревьюверами
+func (r *prRepository) get(ctx context.Context, prID string, forUpdate bool) (*models.PullRequest, error) {
 	query := `
-		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at,
+			ARRAY(SELECT t.tag FROM pr_tags t WHERE t.pull_request_id = pull_requests.pull_request_id ORDER BY t.tag)
 		FROM pull_requests
 		WHERE pull_request_id = $1
 	`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
 
 	var pr models.PullRequest
 	var createdAt time.Time
 	var mergedAt sql.NullTime
+	var closedAt sql.NullTime
 
-	err := r.db.QueryRowContext(ctx, query, prID).Scan(
+	err := r.conn(ctx).QueryRowContext(ctx, query, prID).Scan(
 		&pr.PullRequestID,
 		&pr.PullRequestName,
 		&pr.AuthorID,
 		&pr.Status,
 		&createdAt,
 		&mergedAt,
+		&closedAt,
+		pq.Array(&pr.Tags),
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("pull request not found")
+			return nil, ErrPRNotFound
 		}
 		return nil, fmt.Errorf("failed to get pull request: %w", err)
 	}
@@ -89,6 +110,9 @@ func (r *prRepository) Get(ctx context.Context, prID string) (*models.PullReques
 	if mergedAt.Valid {
 		pr.MergedAt = &mergedAt.Time
 	}
+	if closedAt.Valid {
+		pr.ClosedAt = &closedAt.Time
+	}
 
 	// Получаем ревьюверов
 	reviewers, err := r.GetReviewers(ctx, prID)
@@ -104,11 +128,11 @@ func (r *prRepository) Get(ctx context.Context, prID string) (*models.PullReques
 func (r *prRepository) Update(ctx context.Context, pr *models.PullRequest) error {
 	query := `
 		UPDATE pull_requests
-		SET pull_request_name = $1, status = $2, merged_at = $3
-		WHERE pull_request_id = $4
+		SET pull_request_name = $1, status = $2, merged_at = $3, closed_at = $4
+		WHERE pull_request_id = $5
 	`
 
-	result, err := r.db.ExecContext(ctx, query, pr.PullRequestName, pr.Status, pr.MergedAt, pr.PullRequestID)
+	result, err := r.conn(ctx).ExecContext(ctx, query, pr.PullRequestName, pr.Status, pr.MergedAt, pr.ClosedAt, pr.PullRequestID)
 	if err != nil {
 		return fmt.Errorf("failed to update pull request: %w", err)
 	}
@@ -119,7 +143,7 @@ func (r *prRepository) Update(ctx context.Context, pr *models.PullRequest) error
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("pull request not found")
+		return ErrPRNotFound
 	}
 
 	return nil
@@ -129,7 +153,7 @@ func (r *prRepository) Update(ctx context.Context, pr *models.PullRequest) error
 func (r *prRepository) Exists(ctx context.Context, prID string) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id = $1)`
 	var exists bool
-	err := r.db.QueryRowContext(ctx, query, prID).Scan(&exists)
+	err := r.conn(ctx).QueryRowContext(ctx, query, prID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check pull request existence: %w", err)
 	}
@@ -146,7 +170,7 @@ func (r *prRepository) GetByReviewer(ctx context.Context, reviewerID string) ([]
 		ORDER BY pr.created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, reviewerID)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, reviewerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pull requests by reviewer: %w", err)
 	}
@@ -170,35 +194,49 @@ func (r *prRepository) GetByReviewer(ctx context.Context, reviewerID string) ([]
 	return prs, nil
 }
 
-// AssignReviewer назначает ревьювера на PR
-func (r *prRepository) AssignReviewer(ctx context.Context, prID, reviewerID string) error {
-	tx, err := r.db.BeginTx(ctx, nil)
+// GetOpenAssignments одним запросом возвращает назначения указанных
+// ревьюверов на открытые PR
+func (r *prRepository) GetOpenAssignments(ctx context.Context, reviewerIDs []string) ([]models.ReviewAssignment, error) {
+	query := `
+		SELECT prr.pull_request_id, prr.reviewer_id
+		FROM pr_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+		WHERE pr.status = $1 AND prr.reviewer_id = ANY($2)
+		ORDER BY pr.created_at, prr.pull_request_id, prr.reviewer_id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, models.StatusOpen, pq.Array(reviewerIDs))
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to get open assignments: %w", err)
 	}
 	defer func() {
-		_ = tx.Rollback()
+		_ = rows.Close()
 	}()
 
-	if err := r.assignReviewerTx(ctx, tx, prID, reviewerID); err != nil {
-		return err
+	var assignments []models.ReviewAssignment
+	for rows.Next() {
+		var assignment models.ReviewAssignment
+		if err := rows.Scan(&assignment.PullRequestID, &assignment.ReviewerID); err != nil {
+			return nil, fmt.Errorf("failed to scan assignment: %w", err)
+		}
+		assignments = append(assignments, assignment)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
 	}
 
-	return nil
+	return assignments, nil
 }
 
-// assignReviewerTx назначает ревьювера внутри транзакции
-func (r *prRepository) assignReviewerTx(ctx context.Context, tx *sql.Tx, prID, reviewerID string) error {
+// AssignReviewer назначает ревьювера на PR
+func (r *prRepository) AssignReviewer(ctx context.Context, prID, reviewerID string) error {
 	query := `
 		INSERT INTO pr_reviewers (pull_request_id, reviewer_id)
 		VALUES ($1, $2)
 		ON CONFLICT (pull_request_id, reviewer_id) DO NOTHING
 	`
-	_, err := tx.ExecContext(ctx, query, prID, reviewerID)
+	_, err := r.conn(ctx).ExecContext(ctx, query, prID, reviewerID)
 	if err != nil {
 		return fmt.Errorf("failed to assign reviewer: %w", err)
 	}
@@ -212,7 +250,7 @@ func (r *prRepository) RemoveReviewer(ctx context.Context, prID, reviewerID stri
 		WHERE pull_request_id = $1 AND reviewer_id = $2
 	`
 
-	result, err := r.db.ExecContext(ctx, query, prID, reviewerID)
+	result, err := r.conn(ctx).ExecContext(ctx, query, prID, reviewerID)
 	if err != nil {
 		return fmt.Errorf("failed to remove reviewer: %w", err)
 	}
@@ -238,7 +276,7 @@ func (r *prRepository) GetReviewers(ctx context.Context, prID string) ([]string,
 		ORDER BY assigned_at
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, prID)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, prID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get reviewers: %w", err)
 	}
@@ -272,10 +310,191 @@ func (r *prRepository) IsReviewerAssigned(ctx context.Context, prID, reviewerID
 	`
 
 	var exists bool
-	err := r.db.QueryRowContext(ctx, query, prID, reviewerID).Scan(&exists)
+	err := r.conn(ctx).QueryRowContext(ctx, query, prID, reviewerID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check reviewer assignment: %w", err)
 	}
 
 	return exists, nil
 }
+
+// CountOpenReviews возвращает число открытых PR на ревью у пользователей userIDs.
+// Пользователи без открытых ревью в результат не попадают
+func (r *prRepository) CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(userIDs))
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	query := `
+		SELECT prr.reviewer_id, COUNT(*)
+		FROM pr_reviewers prr
+		JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+		WHERE prr.reviewer_id = ANY($1) AND pr.status = $2
+		GROUP BY prr.reviewer_id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, pq.Array(userIDs), models.StatusOpen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count open reviews: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan review count: %w", err)
+		}
+		counts[userID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return counts, nil
+}
+
+// SetReviewDecision сохраняет решение ревьювера
+func (r *prRepository) SetReviewDecision(ctx context.Context, prID, reviewerID string, decision models.ReviewDecision) error {
+	query := `
+		UPDATE pr_reviewers
+		SET review_decision = $1, decided_at = CURRENT_TIMESTAMP
+		WHERE pull_request_id = $2 AND reviewer_id = $3
+	`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, decision, prID, reviewerID)
+	if err != nil {
+		return fmt.Errorf("failed to set review decision: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("reviewer assignment not found")
+	}
+
+	return nil
+}
+
+// GetReviews возвращает решения всех ревьюверов PR
+func (r *prRepository) GetReviews(ctx context.Context, prID string) ([]models.Review, error) {
+	query := `
+		SELECT pull_request_id, reviewer_id, review_decision, decided_at
+		FROM pr_reviewers
+		WHERE pull_request_id = $1
+		ORDER BY assigned_at
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, prID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var reviews []models.Review
+	for rows.Next() {
+		var review models.Review
+		var decidedAt sql.NullTime
+		if err := rows.Scan(&review.PullRequestID, &review.ReviewerID, &review.Decision, &decidedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan review: %w", err)
+		}
+		if decidedAt.Valid {
+			review.DecidedAt = &decidedAt.Time
+		}
+		reviews = append(reviews, review)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return reviews, nil
+}
+
+// SetChangedFiles заменяет список путей, измененных в PR
+func (r *prRepository) SetChangedFiles(ctx context.Context, prID string, paths []string) error {
+	return withTx(ctx, r.db, func(ctx context.Context) error {
+		_, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM pr_changed_files WHERE pull_request_id = $1`, prID)
+		if err != nil {
+			return fmt.Errorf("failed to clear changed files: %w", err)
+		}
+		if len(paths) == 0 {
+			return nil
+		}
+
+		query := `
+			INSERT INTO pr_changed_files (pull_request_id, path)
+			SELECT $1, unnest($2::text[])
+			ON CONFLICT DO NOTHING
+		`
+		if _, err := r.conn(ctx).ExecContext(ctx, query, prID, pq.Array(paths)); err != nil {
+			return fmt.Errorf("failed to add changed files: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetChangedFiles возвращает пути, измененные в PR
+func (r *prRepository) GetChangedFiles(ctx context.Context, prID string) ([]string, error) {
+	query := `
+		SELECT path
+		FROM pr_changed_files
+		WHERE pull_request_id = $1
+		ORDER BY path
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, prID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed files: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan changed file: %w", err)
+		}
+		paths = append(paths, path)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return paths, nil
+}
+
+// SetTags заменяет теги PR
+func (r *prRepository) SetTags(ctx context.Context, prID string, tags []string) error {
+	return withTx(ctx, r.db, func(ctx context.Context) error {
+		_, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM pr_tags WHERE pull_request_id = $1`, prID)
+		if err != nil {
+			return fmt.Errorf("failed to clear pull request tags: %w", err)
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+
+		query := `
+			INSERT INTO pr_tags (pull_request_id, tag)
+			SELECT $1, unnest($2::text[])
+			ON CONFLICT DO NOTHING
+		`
+		if _, err := r.conn(ctx).ExecContext(ctx, query, prID, pq.Array(tags)); err != nil {
+			return fmt.Errorf("failed to add pull request tags: %w", err)
+		}
+		return nil
+	})
+}
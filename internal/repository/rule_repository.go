@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// ruleRepository реализует RuleRepository
+type ruleRepository struct {
+	db *sql.DB
+}
+
+// NewRuleRepository создает новый репозиторий правил назначения ревьюверов
+func NewRuleRepository(db *sql.DB) RuleRepository {
+	return &ruleRepository{db: db}
+}
+
+// conn возвращает транзакцию из контекста или подключение к БД
+func (r *ruleRepository) conn(ctx context.Context) dbExecutor {
+	return executor(ctx, r.db)
+}
+
+// CreateRule создает правило и заполняет его ID
+func (r *ruleRepository) CreateRule(ctx context.Context, rule *models.ReviewerRule) error {
+	query := `
+		INSERT INTO reviewer_rules (kind, user_id, target_id, comment)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, rule.Kind, rule.UserID, rule.TargetID, rule.Comment).Scan(&rule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create reviewer rule: %w", err)
+	}
+	return nil
+}
+
+// UpdateRule заменяет содержимое правила
+func (r *ruleRepository) UpdateRule(ctx context.Context, rule *models.ReviewerRule) error {
+	query := `
+		UPDATE reviewer_rules
+		SET kind = $1, user_id = $2, target_id = $3, comment = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+	`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, rule.Kind, rule.UserID, rule.TargetID, rule.Comment, rule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update reviewer rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("reviewer rule not found")
+	}
+
+	return nil
+}
+
+// DeleteRule удаляет правило
+func (r *ruleRepository) DeleteRule(ctx context.Context, id int64) error {
+	result, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM reviewer_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete reviewer rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("reviewer rule not found")
+	}
+
+	return nil
+}
+
+// ListRules возвращает все правила
+func (r *ruleRepository) ListRules(ctx context.Context) ([]*models.ReviewerRule, error) {
+	query := `
+		SELECT id, kind, user_id, target_id, comment
+		FROM reviewer_rules
+		ORDER BY id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviewer rules: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	rules := []*models.ReviewerRule{}
+	for rows.Next() {
+		var rule models.ReviewerRule
+		if err := rows.Scan(&rule.ID, &rule.Kind, &rule.UserID, &rule.TargetID, &rule.Comment); err != nil {
+			return nil, fmt.Errorf("failed to scan reviewer rule: %w", err)
+		}
+		rules = append(rules, &rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return rules, nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/zazaza5818/pr-reviewer-service/internal/models"
 )
@@ -19,16 +20,114 @@ func NewTeamRepository(db *sql.DB) TeamRepository {
 	return &teamRepository{db: db}
 }
 
+// conn возвращает транзакцию из контекста или подключение к БД
+func (r *teamRepository) conn(ctx context.Context) dbExecutor {
+	return executor(ctx, r.db)
+}
+
 // Create создает новую команду
 func (r *teamRepository) Create(ctx context.Context, team *models.Team) error {
 	query := `INSERT INTO teams (team_name) VALUES ($1)`
-	_, err := r.db.ExecContext(ctx, query, team.TeamName)
+	_, err := r.conn(ctx).ExecContext(ctx, query, team.TeamName)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrTeamExists
+		}
 		return fmt.Errorf("failed to create team: %w", err)
 	}
 	return nil
 }
 
+// CreateWithMembers создает команду и добавляет или обновляет ее участников
+// в одной транзакции. В команду попадают новые пользователи и пользователи
+// без команды. Если команда уже есть, возвращает ErrTeamExists, если
+// участник состоит в другой команде - ErrMemberOfAnotherTeam, и ничего не меняет
+func (r *teamRepository) CreateWithMembers(ctx context.Context, team *models.Team) error {
+	return withTx(ctx, r.db, func(ctx context.Context) error {
+		if err := r.Create(ctx, team); err != nil {
+			return err
+		}
+		if len(team.Members) == 0 {
+			return nil
+		}
+
+		// Один INSERT на всех участников. Лимит ревью, не заданный в запросе,
+		// у существующего пользователя сохраняется
+		const columns = 5
+		values := make([]string, 0, len(team.Members))
+		args := make([]interface{}, 0, len(team.Members)*columns)
+		for i, member := range team.Members {
+			n := i * columns
+			values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5))
+			args = append(args, member.UserID, member.Username, team.TeamName, member.IsActive, member.MaxOpenReviews)
+		}
+
+		query := `
+			INSERT INTO users (user_id, username, team_name, is_active, max_open_reviews)
+			VALUES ` + strings.Join(values, ", ") + `
+			ON CONFLICT (user_id) DO UPDATE
+			SET username = EXCLUDED.username,
+				team_name = EXCLUDED.team_name,
+				is_active = EXCLUDED.is_active,
+				max_open_reviews = COALESCE(EXCLUDED.max_open_reviews, users.max_open_reviews),
+				updated_at = CURRENT_TIMESTAMP
+			WHERE users.team_name IS NULL
+		`
+		result, err := r.conn(ctx).ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to upsert team members: %w", err)
+		}
+
+		// Участник другой команды не обновляется условием WHERE. Переводить
+		// его нужно через MoveMember, чтобы переназначить его ревью
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected != int64(len(team.Members)) {
+			return ErrMemberOfAnotherTeam
+		}
+		return nil
+	})
+}
+
+// Rename переименовывает команду. Ссылки на нее в users, team_settings
+// и team_fallbacks обновляются каскадно, а в owner_teams правил владения
+// кодом - в той же транзакции. Если новое имя занято, возвращает ErrTeamExists
+func (r *teamRepository) Rename(ctx context.Context, oldName, newName string) error {
+	return withTx(ctx, r.db, func(ctx context.Context) error {
+		query := `UPDATE teams SET team_name = $2 WHERE team_name = $1`
+
+		result, err := r.conn(ctx).ExecContext(ctx, query, oldName, newName)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrTeamExists
+			}
+			return fmt.Errorf("failed to rename team: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return ErrTeamNotFound
+		}
+
+		// owner_teams - массив без внешнего ключа, каскад на него не действует
+		query = `
+			UPDATE ownership_rules
+			SET owner_teams = array_replace(owner_teams, $1, $2), updated_at = CURRENT_TIMESTAMP
+			WHERE $1 = ANY(owner_teams)
+		`
+		if _, err := r.conn(ctx).ExecContext(ctx, query, oldName, newName); err != nil {
+			return fmt.Errorf("failed to rename team in ownership rules: %w", err)
+		}
+		return nil
+	})
+}
+
 // Get возвращает команду с участниками
 func (r *teamRepository) Get(ctx context.Context, teamName string) (*models.Team, error) {
 	exists, err := r.Exists(ctx, teamName)
@@ -36,17 +135,17 @@ func (r *teamRepository) Get(ctx context.Context, teamName string) (*models.Team
 		return nil, err
 	}
 	if !exists {
-		return nil, errors.New("team not found")
+		return nil, ErrTeamNotFound
 	}
 
 	query := `
-		SELECT user_id, username, is_active
+		SELECT user_id, username, is_active, max_open_reviews
 		FROM users
 		WHERE team_name = $1
 		ORDER BY username
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, teamName)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, teamName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get team members: %w", err)
 	}
@@ -57,9 +156,14 @@ func (r *teamRepository) Get(ctx context.Context, teamName string) (*models.Team
 	var members []models.TeamMember
 	for rows.Next() {
 		var member models.TeamMember
-		if err := rows.Scan(&member.UserID, &member.Username, &member.IsActive); err != nil {
+		var maxOpenReviews sql.NullInt64
+		if err := rows.Scan(&member.UserID, &member.Username, &member.IsActive, &maxOpenReviews); err != nil {
 			return nil, fmt.Errorf("failed to scan team member: %w", err)
 		}
+		if maxOpenReviews.Valid {
+			limit := int(maxOpenReviews.Int64)
+			member.MaxOpenReviews = &limit
+		}
 		members = append(members, member)
 	}
 
@@ -77,9 +181,122 @@ func (r *teamRepository) Get(ctx context.Context, teamName string) (*models.Team
 func (r *teamRepository) Exists(ctx context.Context, teamName string) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)`
 	var exists bool
-	err := r.db.QueryRowContext(ctx, query, teamName).Scan(&exists)
+	err := r.conn(ctx).QueryRowContext(ctx, query, teamName).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check team existence: %w", err)
 	}
 	return exists, nil
 }
+
+// GetSettings возвращает настройки команды, подставляя значения по умолчанию
+func (r *teamRepository) GetSettings(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+	query := `
+		SELECT t.team_name,
+			COALESCE(ts.min_reviewers, $2),
+			COALESCE(ts.max_reviewers, $3),
+			COALESCE(ts.required_approvals, 0),
+			COALESCE(ts.prefer_working_hours, false),
+			COALESCE(ts.required_seniority, ''),
+			COALESCE(ts.required_seniority_count, 0)
+		FROM teams t
+		LEFT JOIN team_settings ts ON ts.team_name = t.team_name
+		WHERE t.team_name = $1
+	`
+
+	var settings models.TeamSettings
+	err := r.conn(ctx).QueryRowContext(ctx, query, teamName, models.DefaultMinReviewers, models.DefaultMaxReviewers).Scan(
+		&settings.TeamName,
+		&settings.MinReviewers,
+		&settings.MaxReviewers,
+		&settings.RequiredApprovals,
+		&settings.PreferWorkingHours,
+		&settings.RequiredSeniority,
+		&settings.RequiredSeniorityCount,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("failed to get team settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// UpsertSettings создает или обновляет настройки команды
+func (r *teamRepository) UpsertSettings(ctx context.Context, settings *models.TeamSettings) error {
+	query := `
+		INSERT INTO team_settings (team_name, min_reviewers, max_reviewers, required_approvals, prefer_working_hours,
+			required_seniority, required_seniority_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (team_name) DO UPDATE
+		SET min_reviewers = EXCLUDED.min_reviewers,
+			max_reviewers = EXCLUDED.max_reviewers,
+			required_approvals = EXCLUDED.required_approvals,
+			prefer_working_hours = EXCLUDED.prefer_working_hours,
+			required_seniority = EXCLUDED.required_seniority,
+			required_seniority_count = EXCLUDED.required_seniority_count,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := r.conn(ctx).ExecContext(ctx, query, settings.TeamName, settings.MinReviewers, settings.MaxReviewers,
+		settings.RequiredApprovals, settings.PreferWorkingHours, nullString(string(settings.RequiredSeniority)),
+		settings.RequiredSeniorityCount)
+	if err != nil {
+		return fmt.Errorf("failed to upsert team settings: %w", err)
+	}
+	return nil
+}
+
+// GetFallbackTeams возвращает резервные команды в порядке приоритета
+func (r *teamRepository) GetFallbackTeams(ctx context.Context, teamName string) ([]string, error) {
+	query := `
+		SELECT fallback_team
+		FROM team_fallbacks
+		WHERE team_name = $1
+		ORDER BY position
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fallback teams: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var teams []string
+	for rows.Next() {
+		var team string
+		if err := rows.Scan(&team); err != nil {
+			return nil, fmt.Errorf("failed to scan fallback team: %w", err)
+		}
+		teams = append(teams, team)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return teams, nil
+}
+
+// SetFallbackTeams заменяет список резервных команд
+func (r *teamRepository) SetFallbackTeams(ctx context.Context, teamName string, fallbackTeams []string) error {
+	return withTx(ctx, r.db, func(ctx context.Context) error {
+		if _, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM team_fallbacks WHERE team_name = $1`, teamName); err != nil {
+			return fmt.Errorf("failed to clear fallback teams: %w", err)
+		}
+
+		query := `
+			INSERT INTO team_fallbacks (team_name, fallback_team, position)
+			VALUES ($1, $2, $3)
+		`
+		for i, fallbackTeam := range fallbackTeams {
+			if _, err := r.conn(ctx).ExecContext(ctx, query, teamName, fallbackTeam, i); err != nil {
+				return fmt.Errorf("failed to add fallback team: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,154 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/database/dbtest"
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+func TestTeamRenameUpdatesOwnershipRules(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	teamRepo := repository.NewTeamRepository(db)
+	ownershipRepo := repository.NewOwnershipRepository(db)
+
+	for _, team := range []string{"platform", "mobile"} {
+		if err := teamRepo.Create(ctx, &models.Team{TeamName: team}); err != nil {
+			t.Fatalf("failed to create team: %v", err)
+		}
+	}
+	rule := &models.OwnershipRule{Pattern: "/infra/**", Teams: []string{"mobile", "platform"}}
+	if err := ownershipRepo.CreateRule(ctx, rule); err != nil {
+		t.Fatalf("failed to create ownership rule: %v", err)
+	}
+
+	if err := teamRepo.Rename(ctx, "platform", "infrastructure"); err != nil {
+		t.Fatalf("failed to rename team: %v", err)
+	}
+
+	rules, err := ownershipRepo.ListRules(ctx)
+	if err != nil {
+		t.Fatalf("failed to list ownership rules: %v", err)
+	}
+	if len(rules) != 1 {
+		t.Fatalf("got %d rules, want 1", len(rules))
+	}
+	if want := []string{"mobile", "infrastructure"}; !reflect.DeepEqual(rules[0].Teams, want) {
+		t.Errorf("owner teams = %v, want %v", rules[0].Teams, want)
+	}
+}
+
+func TestCreateWithMembersRollsBackOnInvalidMember(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	teamRepo := repository.NewTeamRepository(db)
+	userRepo := repository.NewUserRepository(db)
+
+	// Отрицательный лимит нарушает CHECK на users.max_open_reviews
+	invalid := -1
+	team := &models.Team{
+		TeamName: "backend",
+		Members: []models.TeamMember{
+			{UserID: "alice", Username: "Alice", IsActive: true},
+			{UserID: "bob", Username: "Bob", IsActive: true, MaxOpenReviews: &invalid},
+		},
+	}
+	if err := teamRepo.CreateWithMembers(ctx, team); err == nil {
+		t.Fatal("CreateWithMembers() error = nil, want check violation")
+	}
+
+	exists, err := teamRepo.Exists(ctx, "backend")
+	if err != nil {
+		t.Fatalf("failed to check team: %v", err)
+	}
+	if exists {
+		t.Error("team was created despite the invalid member")
+	}
+	if _, err := userRepo.Get(ctx, "alice"); err == nil {
+		t.Error("valid member was saved despite the invalid one")
+	}
+}
+
+func TestCreateWithMembersRejectsMemberOfAnotherTeam(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	teamRepo := repository.NewTeamRepository(db)
+
+	backend := &models.Team{
+		TeamName: "backend",
+		Members:  []models.TeamMember{{UserID: "alice", Username: "Alice", IsActive: true}},
+	}
+	if err := teamRepo.CreateWithMembers(ctx, backend); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+
+	// Участника другой команды нужно переводить с переназначением ревью
+	platform := &models.Team{
+		TeamName: "platform",
+		Members: []models.TeamMember{
+			{UserID: "bob", Username: "Bob", IsActive: true},
+			{UserID: "alice", Username: "Alice", IsActive: true},
+		},
+	}
+	if err := teamRepo.CreateWithMembers(ctx, platform); !errors.Is(err, repository.ErrMemberOfAnotherTeam) {
+		t.Fatalf("CreateWithMembers() error = %v, want %v", err, repository.ErrMemberOfAnotherTeam)
+	}
+
+	exists, err := teamRepo.Exists(ctx, "platform")
+	if err != nil {
+		t.Fatalf("failed to check team: %v", err)
+	}
+	if exists {
+		t.Error("team was created despite the error")
+	}
+	got, err := teamRepo.Get(ctx, "backend")
+	if err != nil {
+		t.Fatalf("failed to get team: %v", err)
+	}
+	if len(got.Members) != 1 || got.Members[0].UserID != "alice" {
+		t.Errorf("backend members = %+v, want alice", got.Members)
+	}
+}
+
+func TestCreateWithMembersKeepsReviewLimit(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	teamRepo := repository.NewTeamRepository(db)
+	userRepo := repository.NewUserRepository(db)
+
+	limit := 2
+	if err := userRepo.Create(ctx, &models.User{UserID: "alice", Username: "Alice", IsActive: true, MaxOpenReviews: &limit}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	// Пользователь без команды попадает в новую команду, лимит в запросе не задан
+	team := &models.Team{
+		TeamName: "backend",
+		Members:  []models.TeamMember{{UserID: "alice", Username: "Alice", IsActive: true}},
+	}
+	if err := teamRepo.CreateWithMembers(ctx, team); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+
+	user, err := userRepo.Get(ctx, "alice")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	if user.TeamName != "backend" || user.MaxOpenReviews == nil || *user.MaxOpenReviews != limit {
+		t.Errorf("user = %+v, want backend member with max_open_reviews %d", user, limit)
+	}
+}
+
+func TestRenameMissingTeam(t *testing.T) {
+	db := dbtest.Open(t)
+	teamRepo := repository.NewTeamRepository(db)
+
+	if err := teamRepo.Rename(context.Background(), "backend", "platform"); !errors.Is(err, repository.ErrTeamNotFound) {
+		t.Errorf("Rename() error = %v, want %v", err, repository.ErrTeamNotFound)
+	}
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Transactor выполняет группу операций репозиториев в одной транзакции
+type Transactor interface {
+	// WithinTransaction вызывает fn с контекстом, в котором все методы
+	// репозиториев работают в общей транзакции. Если fn возвращает ошибку,
+	// транзакция откатывается. Вложенные вызовы используют внешнюю транзакцию
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// txKey - ключ контекста для хранения текущей транзакции
+type txKey struct{}
+
+// dbExecutor - общий интерфейс для *sql.DB и *sql.Tx
+type dbExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// transactor реализует Transactor
+type transactor struct {
+	db *sql.DB
+}
+
+// NewTransactor создает менеджер транзакций
+func NewTransactor(db *sql.DB) Transactor {
+	return &transactor{db: db}
+}
+
+// WithinTransaction реализует Transactor
+func (t *transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTx(ctx, t.db, fn)
+}
+
+// withTx выполняет fn в транзакции из контекста или в новой транзакции
+func withTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// executor возвращает транзакцию из контекста или подключение к БД
+func executor(ctx context.Context, db *sql.DB) dbExecutor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
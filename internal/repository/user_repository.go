@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/lib/pq"
 	"github.com/zazaza5818/pr-reviewer-service/internal/models"
 )
 
@@ -14,19 +15,67 @@ type userRepository struct {
 	db *sql.DB
 }
 
+// userColumns - список колонок, читаемых scanUser
+const userColumns = `user_id, username, team_name, is_active, max_open_reviews,
+	timezone, to_char(work_start, 'HH24:MI'), to_char(work_end, 'HH24:MI'),
+	ARRAY(SELECT s.tag FROM user_skills s WHERE s.user_id = users.user_id ORDER BY s.tag), seniority`
+
+// availableCondition исключает пользователей, у которых сейчас идет период отсутствия
+const availableCondition = `NOT EXISTS (
+			SELECT 1 FROM user_availability a
+			WHERE a.user_id = users.user_id
+			  AND a.starts_at <= CURRENT_TIMESTAMP AND a.ends_at > CURRENT_TIMESTAMP
+		  )`
+
+// rowScanner - общий интерфейс для sql.Row и sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUser читает пользователя из строки результата
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	var teamName sql.NullString
+	var maxOpenReviews sql.NullInt64
+	var timezone, workStart, workEnd, seniority sql.NullString
+	if err := row.Scan(&user.UserID, &user.Username, &teamName, &user.IsActive, &maxOpenReviews,
+		&timezone, &workStart, &workEnd, pq.Array(&user.Skills), &seniority); err != nil {
+		return nil, err
+	}
+	user.Seniority = models.Seniority(seniority.String)
+	user.Timezone = timezone.String
+	user.WorkStart = workStart.String
+	user.WorkEnd = workEnd.String
+	// Пользователь, исключенный из команды, хранится с team_name = NULL
+	user.TeamName = teamName.String
+	if maxOpenReviews.Valid {
+		limit := int(maxOpenReviews.Int64)
+		user.MaxOpenReviews = &limit
+	}
+	return &user, nil
+}
+
 // NewUserRepository создает новый репозиторий пользователей
 func NewUserRepository(db *sql.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// conn возвращает транзакцию из контекста или подключение к БД
+func (r *userRepository) conn(ctx context.Context) dbExecutor {
+	return executor(ctx, r.db)
+}
+
 // Create создает нового пользователя
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (user_id, username, team_name, is_active)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (user_id, username, team_name, is_active, max_open_reviews)
+		VALUES ($1, $2, $3, $4, $5)
 	`
-	_, err := r.db.ExecContext(ctx, query, user.UserID, user.Username, user.TeamName, user.IsActive)
+	_, err := r.conn(ctx).ExecContext(ctx, query, user.UserID, user.Username, nullString(user.TeamName), user.IsActive, user.MaxOpenReviews)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrUserExists
+		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 	return nil
@@ -36,10 +85,10 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users
-		SET username = $1, team_name = $2, is_active = $3, updated_at = CURRENT_TIMESTAMP
-		WHERE user_id = $4
+		SET username = $1, team_name = $2, is_active = $3, max_open_reviews = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $5
 	`
-	result, err := r.db.ExecContext(ctx, query, user.Username, user.TeamName, user.IsActive, user.UserID)
+	result, err := r.conn(ctx).ExecContext(ctx, query, user.Username, nullString(user.TeamName), user.IsActive, user.MaxOpenReviews, user.UserID)
 	if err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
 	}
@@ -59,19 +108,12 @@ func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 // Get возвращает пользователя по ID
 func (r *userRepository) Get(ctx context.Context, userID string) (*models.User, error) {
 	query := `
-		SELECT user_id, username, team_name, is_active
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE user_id = $1
 	`
 
-	var user models.User
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(
-		&user.UserID,
-		&user.Username,
-		&user.TeamName,
-		&user.IsActive,
-	)
-
+	user, err := scanUser(r.conn(ctx).QueryRowContext(ctx, query, userID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errors.New("user not found")
@@ -79,19 +121,19 @@ func (r *userRepository) Get(ctx context.Context, userID string) (*models.User,
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 // GetByTeam возвращает всех пользователей команды
 func (r *userRepository) GetByTeam(ctx context.Context, teamName string) ([]*models.User, error) {
 	query := `
-		SELECT user_id, username, team_name, is_active
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE team_name = $1
 		ORDER BY username
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, teamName)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, teamName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users by team: %w", err)
 	}
@@ -101,11 +143,11 @@ func (r *userRepository) GetByTeam(ctx context.Context, teamName string) ([]*mod
 
 	var users []*models.User
 	for rows.Next() {
-		var user models.User
-		if err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive); err != nil {
+		user, err := scanUser(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
-		users = append(users, &user)
+		users = append(users, user)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -123,7 +165,7 @@ func (r *userRepository) SetActive(ctx context.Context, userID string, isActive
 		WHERE user_id = $2
 	`
 
-	result, err := r.db.ExecContext(ctx, query, isActive, userID)
+	result, err := r.conn(ctx).ExecContext(ctx, query, isActive, userID)
 	if err != nil {
 		return fmt.Errorf("failed to set user active status: %w", err)
 	}
@@ -140,16 +182,152 @@ func (r *userRepository) SetActive(ctx context.Context, userID string, isActive
 	return nil
 }
 
-// GetActiveTeammates возвращает активных участников команды, исключая указанного пользователя
+// SetTeam переводит пользователя в команду. Пустое имя исключает его из команды
+func (r *userRepository) SetTeam(ctx context.Context, userID, teamName string) error {
+	query := `
+		UPDATE users
+		SET team_name = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2
+	`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, nullString(teamName), userID)
+	if err != nil {
+		return fmt.Errorf("failed to set user team: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// SetWorkingHours задает часовой пояс и рабочие часы пользователя.
+// Пустые значения сбрасывают их
+func (r *userRepository) SetWorkingHours(ctx context.Context, userID, timezone, workStart, workEnd string) error {
+	query := `
+		UPDATE users
+		SET timezone = $1, work_start = $2::time, work_end = $3::time, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $4
+	`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, nullString(timezone), nullString(workStart), nullString(workEnd), userID)
+	if err != nil {
+		return fmt.Errorf("failed to set working hours: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// SetSeniority задает уровень пользователя. Пустое значение сбрасывает его
+func (r *userRepository) SetSeniority(ctx context.Context, userID string, seniority models.Seniority) error {
+	query := `
+		UPDATE users
+		SET seniority = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2
+	`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, nullString(string(seniority)), userID)
+	if err != nil {
+		return fmt.Errorf("failed to set seniority: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// SetSkills заменяет навыки пользователя
+func (r *userRepository) SetSkills(ctx context.Context, userID string, skills []string) error {
+	return withTx(ctx, r.db, func(ctx context.Context) error {
+		_, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM user_skills WHERE user_id = $1`, userID)
+		if err != nil {
+			return fmt.Errorf("failed to clear user skills: %w", err)
+		}
+		if len(skills) == 0 {
+			return nil
+		}
+
+		query := `
+			INSERT INTO user_skills (user_id, tag)
+			SELECT $1, unnest($2::text[])
+			ON CONFLICT DO NOTHING
+		`
+		if _, err := r.conn(ctx).ExecContext(ctx, query, userID, pq.Array(skills)); err != nil {
+			return fmt.Errorf("failed to add user skills: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeactivateMany одним запросом деактивирует активных участников команды.
+// Пустой userIDs означает всю команду. Возвращает только пользователей,
+// чей статус изменился
+func (r *userRepository) DeactivateMany(ctx context.Context, teamName string, userIDs []string) ([]*models.User, error) {
+	query := `
+		UPDATE users
+		SET is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE team_name = $1 AND is_active = true
+		  AND (cardinality($2::text[]) = 0 OR user_id = ANY($2))
+		RETURNING ` + userColumns
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, teamName, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to deactivate users: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var users []*models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetActiveTeammates возвращает активных участников команды, исключая указанного
+// пользователя и тех, у кого сейчас идет период отсутствия
 func (r *userRepository) GetActiveTeammates(ctx context.Context, teamName string, excludeUserID string) ([]*models.User, error) {
 	query := `
-		SELECT user_id, username, team_name, is_active
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE team_name = $1 AND user_id != $2 AND is_active = true
+		  AND ` + availableCondition + `
 		ORDER BY username
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, teamName, excludeUserID)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, teamName, excludeUserID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get active teammates: %w", err)
 	}
@@ -159,11 +337,46 @@ func (r *userRepository) GetActiveTeammates(ctx context.Context, teamName string
 
 	var users []*models.User
 	for rows.Next() {
-		var user models.User
-		if err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive); err != nil {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetAvailableByIDs возвращает активных пользователей из списка, у которых
+// сейчас нет периода отсутствия
+func (r *userRepository) GetAvailableByIDs(ctx context.Context, userIDs []string) ([]*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE user_id = ANY($1) AND is_active = true
+		  AND ` + availableCondition + `
+		ORDER BY username
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available users: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var users []*models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
-		users = append(users, &user)
+		users = append(users, user)
 	}
 
 	if err := rows.Err(); err != nil {
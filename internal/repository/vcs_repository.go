@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// vcsRepository реализует VCSRepository
+type vcsRepository struct {
+	db *sql.DB
+}
+
+// NewVCSRepository создает новый репозиторий учетных записей внешних систем
+func NewVCSRepository(db *sql.DB) VCSRepository {
+	return &vcsRepository{db: db}
+}
+
+// conn возвращает транзакцию из контекста или подключение к БД
+func (r *vcsRepository) conn(ctx context.Context) dbExecutor {
+	return executor(ctx, r.db)
+}
+
+// UpsertIdentity создает или обновляет сопоставление логина и пользователя
+func (r *vcsRepository) UpsertIdentity(ctx context.Context, identity *models.VCSIdentity) error {
+	query := `
+		INSERT INTO vcs_identities (provider, login, user_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (provider, login) DO UPDATE
+		SET user_id = EXCLUDED.user_id
+	`
+	_, err := r.conn(ctx).ExecContext(ctx, query, identity.Provider, identity.Login, identity.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to upsert vcs identity: %w", err)
+	}
+	return nil
+}
+
+// ResolveUserID возвращает user_id по логину во внешней системе
+func (r *vcsRepository) ResolveUserID(ctx context.Context, provider models.VCSProvider, login string) (string, error) {
+	query := `
+		SELECT user_id
+		FROM vcs_identities
+		WHERE provider = $1 AND login = $2
+	`
+
+	var userID string
+	err := r.conn(ctx).QueryRowContext(ctx, query, provider, login).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrIdentityNotFound
+		}
+		return "", fmt.Errorf("failed to resolve vcs identity: %w", err)
+	}
+	return userID, nil
+}
+
+// MarkDelivery запоминает доставку вебхука. Возвращает false, если она уже обрабатывалась
+func (r *vcsRepository) MarkDelivery(ctx context.Context, provider models.VCSProvider, deliveryID string) (bool, error) {
+	query := `
+		INSERT INTO vcs_deliveries (provider, delivery_id)
+		VALUES ($1, $2)
+		ON CONFLICT (provider, delivery_id) DO NOTHING
+	`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, provider, deliveryID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark vcs delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// webhookRepository реализует WebhookRepository
+type webhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository создает новый репозиторий исходящих вебхуков
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// conn возвращает транзакцию из контекста или подключение к БД
+func (r *webhookRepository) conn(ctx context.Context) dbExecutor {
+	return executor(ctx, r.db)
+}
+
+// CreateSubscription создает подписку и заполняет ее id и created_at
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (url, secret, event_types, is_active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := r.conn(ctx).QueryRowContext(ctx, query,
+		sub.URL,
+		sub.Secret,
+		pq.Array(eventTypeStrings(sub.EventTypes)),
+		sub.IsActive,
+	).Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// GetSubscription возвращает подписку по id
+func (r *webhookRepository) GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	query := `
+		SELECT id, url, secret, event_types, is_active, created_at
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`
+
+	subs, err := r.querySubscriptions(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return nil, errors.New("webhook subscription not found")
+	}
+	return subs[0], nil
+}
+
+// ListSubscriptions возвращает все подписки
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	query := `
+		SELECT id, url, secret, event_types, is_active, created_at
+		FROM webhook_subscriptions
+		ORDER BY id
+	`
+	return r.querySubscriptions(ctx, query)
+}
+
+// DeleteSubscription удаляет подписку вместе с журналом доставки
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	query := `DELETE FROM webhook_subscriptions WHERE id = $1`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("webhook subscription not found")
+	}
+
+	return nil
+}
+
+// ListSubscriptionsForEvent возвращает активные подписки, фильтр которых пропускает событие
+func (r *webhookRepository) ListSubscriptionsForEvent(ctx context.Context, eventType models.WebhookEventType) ([]*models.WebhookSubscription, error) {
+	query := `
+		SELECT id, url, secret, event_types, is_active, created_at
+		FROM webhook_subscriptions
+		WHERE is_active = true
+		  AND (cardinality(event_types) = 0 OR $1 = ANY(event_types))
+		ORDER BY id
+	`
+	return r.querySubscriptions(ctx, query, eventType)
+}
+
+// querySubscriptions выполняет запрос и читает подписки
+func (r *webhookRepository) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]*models.WebhookSubscription, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	subs := []*models.WebhookSubscription{}
+	for rows.Next() {
+		var sub models.WebhookSubscription
+		var eventTypes []string
+		if err := rows.Scan(
+			&sub.ID,
+			&sub.URL,
+			&sub.Secret,
+			pq.Array(&eventTypes),
+			&sub.IsActive,
+			&sub.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		sub.EventTypes = make([]models.WebhookEventType, 0, len(eventTypes))
+		for _, eventType := range eventTypes {
+			sub.EventTypes = append(sub.EventTypes, models.WebhookEventType(eventType))
+		}
+		subs = append(subs, &sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return subs, nil
+}
+
+// AddDelivery записывает попытку доставки в журнал
+func (r *webhookRepository) AddDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries
+			(subscription_id, event_id, event_type, attempt, status_code, error, succeeded)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	var statusCode sql.NullInt64
+	if delivery.StatusCode != nil {
+		statusCode = sql.NullInt64{Int64: int64(*delivery.StatusCode), Valid: true}
+	}
+
+	err := r.conn(ctx).QueryRowContext(ctx, query,
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Attempt,
+		statusCode,
+		nullString(delivery.Error),
+		delivery.Succeeded,
+	).Scan(&delivery.ID, &delivery.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// GetDeliveries возвращает последние limit попыток доставки подписки, новые первыми
+func (r *webhookRepository) GetDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*models.WebhookDelivery, error) {
+	query := `
+		SELECT id, subscription_id, event_id, event_type, attempt, status_code, error, succeeded, created_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		var statusCode sql.NullInt64
+		var deliveryErr sql.NullString
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.EventID,
+			&delivery.EventType,
+			&delivery.Attempt,
+			&statusCode,
+			&deliveryErr,
+			&delivery.Succeeded,
+			&delivery.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		if statusCode.Valid {
+			code := int(statusCode.Int64)
+			delivery.StatusCode = &code
+		}
+		delivery.Error = deliveryErr.String
+		deliveries = append(deliveries, &delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// AddJobs создает задания доставки. Задание, уже созданное для той же
+// подписки и события, не дублируется
+func (r *webhookRepository) AddJobs(ctx context.Context, jobs []*models.WebhookJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	// Один INSERT на все задания
+	const columns = 4
+	values := make([]string, 0, len(jobs))
+	args := make([]interface{}, 0, len(jobs)*columns)
+	for i, job := range jobs {
+		n := i * columns
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4))
+		args = append(args, job.SubscriptionID, job.EventID, job.EventType, string(job.Body))
+	}
+
+	query := `
+		INSERT INTO webhook_jobs (subscription_id, event_id, event_type, body)
+		VALUES ` + strings.Join(values, ", ") + `
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`
+	if _, err := r.conn(ctx).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to add webhook jobs: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueJobs возвращает до limit незавершенных заданий, время попытки
+// которых наступило. Строки блокируются до конца транзакции из контекста,
+// поэтому одно задание не доставляется параллельно несколькими обработчиками
+func (r *webhookRepository) ClaimDueJobs(ctx context.Context, limit int) ([]*models.WebhookJob, error) {
+	query := `
+		SELECT j.id, j.subscription_id, s.url, s.secret, j.event_id, j.event_type, j.body, j.attempts
+		FROM webhook_jobs j
+		JOIN webhook_subscriptions s ON s.id = j.subscription_id
+		WHERE j.completed_at IS NULL AND j.next_attempt_at <= CURRENT_TIMESTAMP
+		ORDER BY j.next_attempt_at, j.id
+		LIMIT $1
+		FOR UPDATE OF j SKIP LOCKED
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook jobs: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	jobs := []*models.WebhookJob{}
+	for rows.Next() {
+		var job models.WebhookJob
+		var body string
+		if err := rows.Scan(
+			&job.ID,
+			&job.SubscriptionID,
+			&job.URL,
+			&job.Secret,
+			&job.EventID,
+			&job.EventType,
+			&body,
+			&job.Attempts,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook job: %w", err)
+		}
+		job.Body = []byte(body)
+		jobs = append(jobs, &job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// CompleteJob засчитывает попытку и завершает задание. Непустой reason
+// означает, что доставка прекращена без успеха
+func (r *webhookRepository) CompleteJob(ctx context.Context, id int64, reason string) error {
+	query := `
+		UPDATE webhook_jobs
+		SET attempts = attempts + 1, last_error = $2, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	if _, err := r.conn(ctx).ExecContext(ctx, query, id, nullString(reason)); err != nil {
+		return fmt.Errorf("failed to complete webhook job: %w", err)
+	}
+	return nil
+}
+
+// RescheduleJob засчитывает неудачную попытку и откладывает следующую на delay
+func (r *webhookRepository) RescheduleJob(ctx context.Context, id int64, delay time.Duration, reason string) error {
+	query := `
+		UPDATE webhook_jobs
+		SET attempts = attempts + 1,
+			last_error = $2,
+			next_attempt_at = CURRENT_TIMESTAMP + $3 * INTERVAL '1 microsecond'
+		WHERE id = $1
+	`
+
+	if _, err := r.conn(ctx).ExecContext(ctx, query, id, reason, delay.Microseconds()); err != nil {
+		return fmt.Errorf("failed to reschedule webhook job: %w", err)
+	}
+	return nil
+}
+
+// eventTypeStrings приводит типы событий к строкам для pq.Array
+func eventTypeStrings(eventTypes []models.WebhookEventType) []string {
+	result := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		result = append(result, string(eventType))
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// AbsenceSchedulerConfig описывает параметры обработки периодов отсутствия
+type AbsenceSchedulerConfig struct {
+	// PollInterval - пауза между проверками начавшихся отсутствий
+	PollInterval time.Duration
+	// BatchSize - сколько периодов обрабатывается за одну проверку
+	BatchSize int
+	// RetryDelay - через сколько повторить период, переназначение которого не удалось
+	RetryDelay time.Duration
+}
+
+// AbsenceScheduler переназначает открытые ревью пользователей, у которых
+// начался период отсутствия. Новых ревью они не получают и без него:
+// GetActiveTeammates исключает отсутствующих
+type AbsenceScheduler struct {
+	availabilityRepo repository.AvailabilityRepository
+	tx               repository.Transactor
+	prService        PullRequestService
+	cfg              AbsenceSchedulerConfig
+}
+
+// NewAbsenceScheduler создает планировщик. Обработка начинается после вызова Run
+func NewAbsenceScheduler(
+	availabilityRepo repository.AvailabilityRepository,
+	tx repository.Transactor,
+	prService PullRequestService,
+	cfg AbsenceSchedulerConfig,
+) *AbsenceScheduler {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Minute
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 15 * time.Minute
+	}
+
+	return &AbsenceScheduler{
+		availabilityRepo: availabilityRepo,
+		tx:               tx,
+		prService:        prService,
+		cfg:              cfg,
+	}
+}
+
+// Run обрабатывает начавшиеся отсутствия, пока не будет отменен ctx
+func (s *AbsenceScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := s.processBatch(ctx)
+			if err != nil {
+				log.Printf("Failed to process absence periods: %v", err)
+			}
+			if err != nil || processed < s.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processBatch обрабатывает до BatchSize начавшихся периодов и возвращает
+// их число. Каждый период обрабатывается в своей транзакции, поэтому ошибка
+// одного периода не откатывает и не блокирует остальные
+func (s *AbsenceScheduler) processBatch(ctx context.Context) (int, error) {
+	for processed := 0; processed < s.cfg.BatchSize; processed++ {
+		found, err := s.processNext(ctx)
+		if err != nil || !found {
+			return processed, err
+		}
+	}
+	return s.cfg.BatchSize, nil
+}
+
+// processNext переназначает ревью для одного начавшегося периода и сообщает,
+// был ли такой период. Период отмечается в той же транзакции, а при ошибке
+// транзакция откатывается, ошибка записывается в период и повтор
+// откладывается на RetryDelay
+func (s *AbsenceScheduler) processNext(ctx context.Context) (bool, error) {
+	var period *models.AbsencePeriod
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		periods, err := s.availabilityRepo.FetchStarted(ctx, 1)
+		if err != nil || len(periods) == 0 {
+			return err
+		}
+		period = &periods[0]
+
+		report, err := s.prService.ReassignOpenReviews(ctx, []string{period.UserID}, "reviewer out of office")
+		if err != nil {
+			return err
+		}
+		if len(report.NoCandidate) > 0 {
+			log.Printf("No replacement for %d open reviews of absent user %s", len(report.NoCandidate), period.UserID)
+		}
+
+		return s.availabilityRepo.MarkReassigned(ctx, period.ID)
+	})
+	if err == nil || period == nil {
+		return period != nil, err
+	}
+
+	log.Printf("Failed to reassign open reviews of absent user %s: %v", period.UserID, err)
+	if err := s.availabilityRepo.MarkFailed(ctx, period.ID, err.Error(), s.cfg.RetryDelay); err != nil {
+		return true, err
+	}
+	return true, nil
+}
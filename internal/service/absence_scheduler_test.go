@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// fakeAbsencePeriod - период отсутствия в памяти
+type fakeAbsencePeriod struct {
+	period     models.AbsencePeriod
+	reassigned bool
+	// failed - повтор отложен после ошибки
+	failed    bool
+	lastError string
+}
+
+// fakeAvailabilityRepository хранит начавшиеся периоды в памяти.
+// Отложенные после ошибки периоды в этом тесте больше не выбираются
+type fakeAvailabilityRepository struct {
+	repository.AvailabilityRepository
+	periods []*fakeAbsencePeriod
+}
+
+func (r *fakeAvailabilityRepository) FetchStarted(_ context.Context, limit int) ([]models.AbsencePeriod, error) {
+	periods := []models.AbsencePeriod{}
+	for _, stored := range r.periods {
+		if stored.reassigned || stored.failed || len(periods) == limit {
+			continue
+		}
+		periods = append(periods, stored.period)
+	}
+	return periods, nil
+}
+
+func (r *fakeAvailabilityRepository) MarkReassigned(_ context.Context, id int64) error {
+	r.periods[id-1].reassigned = true
+	return nil
+}
+
+func (r *fakeAvailabilityRepository) MarkFailed(_ context.Context, id int64, reason string, _ time.Duration) error {
+	r.periods[id-1].failed = true
+	r.periods[id-1].lastError = reason
+	return nil
+}
+
+// newAbsenceRepository создает по периоду на пользователя в порядке начала
+func newAbsenceRepository(userIDs ...string) *fakeAvailabilityRepository {
+	repo := &fakeAvailabilityRepository{}
+	for i, userID := range userIDs {
+		repo.periods = append(repo.periods, &fakeAbsencePeriod{
+			period: models.AbsencePeriod{ID: int64(i + 1), UserID: userID},
+		})
+	}
+	return repo
+}
+
+func TestAbsenceSchedulerSkipsFailedPeriod(t *testing.T) {
+	// Первый по порядку период не удается обработать
+	repo := newAbsenceRepository("broken", "alice", "bob")
+	prService := &fakeReassignService{fail: map[string]bool{"broken": true}}
+	scheduler := NewAbsenceScheduler(repo, passthroughTransactor{}, prService, AbsenceSchedulerConfig{BatchSize: 10})
+
+	processed, err := scheduler.processBatch(context.Background())
+	if err != nil {
+		t.Fatalf("processBatch() error = %v", err)
+	}
+	if processed != 3 {
+		t.Errorf("processed %d periods, want 3", processed)
+	}
+
+	broken, alice, bob := repo.periods[0], repo.periods[1], repo.periods[2]
+	if broken.reassigned || !broken.failed || broken.lastError != "lock timeout" {
+		t.Errorf("broken period: reassigned %v, failed %v with %q, want failed with lock timeout",
+			broken.reassigned, broken.failed, broken.lastError)
+	}
+	if !alice.reassigned || !bob.reassigned {
+		t.Errorf("periods after the failed one reassigned: alice %v, bob %v, want both", alice.reassigned, bob.reassigned)
+	}
+
+	// Следующая проверка не выбирает отложенный период снова
+	processed, err = scheduler.processBatch(context.Background())
+	if err != nil {
+		t.Fatalf("processBatch() error = %v", err)
+	}
+	if processed != 0 {
+		t.Errorf("second batch processed %d periods, want 0", processed)
+	}
+	if want := []string{"broken", "alice", "bob"}; !reflect.DeepEqual(prService.calls, want) {
+		t.Errorf("reassigned reviews of %v, want %v", prService.calls, want)
+	}
+}
+
+func TestAbsenceSchedulerBatchSize(t *testing.T) {
+	repo := newAbsenceRepository("alice", "bob", "carol")
+	prService := &fakeReassignService{}
+	scheduler := NewAbsenceScheduler(repo, passthroughTransactor{}, prService, AbsenceSchedulerConfig{BatchSize: 2})
+
+	for _, want := range []int{2, 1, 0} {
+		processed, err := scheduler.processBatch(context.Background())
+		if err != nil {
+			t.Fatalf("processBatch() error = %v", err)
+		}
+		if processed != want {
+			t.Errorf("processed %d periods, want %d", processed, want)
+		}
+	}
+	for _, period := range repo.periods {
+		if !period.reassigned {
+			t.Errorf("period of %s is not reassigned", period.period.UserID)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package service
+
+import "context"
+
+// actorKey - ключ контекста для пользователя, выполняющего операцию
+type actorKey struct{}
+
+// WithActor возвращает контекст с ID пользователя, от имени которого
+// выполняется операция. Используется для истории назначений
+func WithActor(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// actorFromContext возвращает ID пользователя, выполняющего операцию
+func actorFromContext(ctx context.Context) string {
+	actorID, _ := ctx.Value(actorKey{}).(string)
+	return actorID
+}
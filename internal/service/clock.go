@@ -0,0 +1,20 @@
+package service
+
+import "time"
+
+// Clock возвращает текущее время. Сервисы получают его через конструктор,
+// чтобы поведение, зависящее от времени, можно было проверять детерминированно
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc позволяет использовать функцию как Clock
+type ClockFunc func() time.Time
+
+// Now возвращает результат вызова функции
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock - часы, возвращающие системное время
+var SystemClock Clock = ClockFunc(time.Now)
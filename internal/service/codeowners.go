@@ -0,0 +1,116 @@
+package service
+
+import (
+	"path"
+	"strings"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// validOwnershipPattern проверяет glob-шаблон правила владения
+func validOwnershipPattern(pattern string) bool {
+	segments, ok := ownershipSegments(pattern)
+	if !ok {
+		return false
+	}
+	for _, segment := range segments {
+		if segment == "**" {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// matchOwnershipPattern сообщает, относится ли путь к шаблону в стиле CODEOWNERS:
+//   - * и ? совпадают внутри одного сегмента, ** - с любым числом сегментов;
+//   - шаблон без / в середине совпадает в любом каталоге, иначе - от корня;
+//   - шаблон, совпавший с каталогом, покрывает все файлы внутри него
+func matchOwnershipPattern(pattern, filePath string) bool {
+	segments, ok := ownershipSegments(pattern)
+	if !ok {
+		return false
+	}
+
+	parts := strings.Split(strings.Trim(filePath, "/"), "/")
+	// Совпадение с любым каталогом-предком означает владение файлом
+	for end := len(parts); end > 0; end-- {
+		if matchSegments(segments, parts[:end]) {
+			return true
+		}
+	}
+	return false
+}
+
+// ownershipSegments разбирает шаблон на сегменты, приводя его к шаблону от корня
+func ownershipSegments(pattern string) ([]string, bool) {
+	pattern = strings.TrimSpace(pattern)
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return nil, false
+	}
+
+	segments := strings.Split(pattern, "/")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, false
+		}
+	}
+	if !anchored && len(segments) == 1 {
+		segments = append([]string{"**"}, segments...)
+	}
+	return segments, true
+}
+
+// matchSegments сопоставляет сегменты шаблона с сегментами пути
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+	matched, err := path.Match(pattern[0], parts[0])
+	return err == nil && matched && matchSegments(pattern[1:], parts[1:])
+}
+
+// ownersForPaths возвращает пользователей и команды, владеющие путями.
+// Для каждого пути действует последнее подходящее правило
+func ownersForPaths(rules []*models.OwnershipRule, paths []string) (users, teams []string) {
+	seenUsers := make(map[string]bool)
+	seenTeams := make(map[string]bool)
+	for _, filePath := range paths {
+		for i := len(rules) - 1; i >= 0; i-- {
+			if !matchOwnershipPattern(rules[i].Pattern, filePath) {
+				continue
+			}
+			for _, userID := range rules[i].Users {
+				if !seenUsers[userID] {
+					seenUsers[userID] = true
+					users = append(users, userID)
+				}
+			}
+			for _, team := range rules[i].Teams {
+				if !seenTeams[team] {
+					seenTeams[team] = true
+					teams = append(teams, team)
+				}
+			}
+			break
+		}
+	}
+	return users, teams
+}
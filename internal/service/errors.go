@@ -7,12 +7,34 @@ import (
 
 // Общие ошибки сервисов
 var (
-	ErrTeamExists       = errors.New("team already exists")
-	ErrTeamNotFound     = errors.New("team not found")
-	ErrUserNotFound     = errors.New("user not found")
-	ErrPRExists         = errors.New("pull request already exists")
-	ErrPRNotFound       = errors.New("pull request not found")
-	ErrPRMerged         = errors.New("cannot modify merged pull request")
-	ErrReviewerNotFound = errors.New("reviewer not assigned to this PR")
-	ErrNoCandidate      = errors.New("no active replacement candidate in team")
+	ErrTeamExists            = errors.New("team already exists")
+	ErrTeamNotFound          = errors.New("team not found")
+	ErrUserNotFound          = errors.New("user not found")
+	ErrPRExists              = errors.New("pull request already exists")
+	ErrPRNotFound            = errors.New("pull request not found")
+	ErrPRMerged              = errors.New("cannot modify merged pull request")
+	ErrInvalidStatus         = errors.New("operation is not allowed in current pull request status")
+	ErrApprovalsRequired     = errors.New("pull request lacks required approvals")
+	ErrReviewerNotFound      = errors.New("reviewer not assigned to this PR")
+	ErrNoCandidate           = errors.New("no active replacement candidate in team")
+	ErrCapacityExhausted     = errors.New("all candidates reached their open review limit")
+	ErrNotEnoughReviewers    = errors.New("not enough candidates to satisfy team min_reviewers")
+	ErrInvalidSettings       = errors.New("invalid team settings")
+	ErrUnknownIdentity       = errors.New("vcs login is not linked to any user")
+	ErrInvalidFallback       = errors.New("fallback team must exist and differ from the team itself")
+	ErrNotTeamMember         = errors.New("user is not a member of the team")
+	ErrMemberOfAnotherTeam   = errors.New("user is a member of another team")
+	ErrInvalidSubscription   = errors.New("invalid webhook subscription")
+	ErrSubscriptionNotFound  = errors.New("webhook subscription not found")
+	ErrInvalidAbsence        = errors.New("invalid absence period")
+	ErrInvalidWorkingHours   = errors.New("invalid timezone or working hours")
+	ErrInvalidOwnershipRule  = errors.New("invalid ownership rule")
+	ErrOwnershipRuleNotFound = errors.New("ownership rule not found")
+	ErrInvalidTags           = errors.New("invalid skills or tags")
+	ErrNoSeniorCandidate     = errors.New("not enough candidates of the seniority required by team")
+	ErrInvalidSeniority      = errors.New("invalid seniority")
+	ErrInvalidReviewerRule   = errors.New("invalid reviewer rule")
+	ErrReviewerRuleNotFound  = errors.New("reviewer rule not found")
+	ErrInvalidPolicy         = errors.New("invalid team policy")
+	ErrTeamConfigured        = errors.New("team is referenced by name in deployment configuration")
 )
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// EventPublisher сохраняет доменные события для последующей публикации.
+// Publish вызывается внутри транзакции изменения, поэтому событие
+// фиксируется тогда и только тогда, когда фиксируется само изменение
+type EventPublisher interface {
+	Publish(ctx context.Context, event *models.WebhookEvent) error
+}
+
+// outboxPublisher реализует EventPublisher записью в таблицу outbox
+type outboxPublisher struct {
+	outboxRepo repository.OutboxRepository
+}
+
+// NewOutboxPublisher создает публикатор, записывающий события в outbox
+func NewOutboxPublisher(outboxRepo repository.OutboxRepository) EventPublisher {
+	return &outboxPublisher{outboxRepo: outboxRepo}
+}
+
+// Publish реализует EventPublisher
+func (p *outboxPublisher) Publish(ctx context.Context, event *models.WebhookEvent) error {
+	return p.outboxRepo.Add(ctx, event)
+}
+
+// newEvent создает событие с уникальным идентификатором и временем по часам сервиса
+func newEvent(clock Clock, eventType models.WebhookEventType, data interface{}) (*models.WebhookEvent, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate event id: %w", err)
+	}
+
+	return &models.WebhookEvent{
+		ID:         hex.EncodeToString(id),
+		Type:       eventType,
+		OccurredAt: clock.Now().UTC(),
+		Data:       data,
+	}, nil
+}
+
+// emit создает событие и передает его публикатору. Должен вызываться
+// в транзакции изменения, чтобы ошибка записи события отменяла изменение
+func emit(ctx context.Context, publisher EventPublisher, clock Clock, eventType models.WebhookEventType, data interface{}) error {
+	event, err := newEvent(clock, eventType, data)
+	if err != nil {
+		return err
+	}
+	if err := publisher.Publish(ctx, event); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", eventType, err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// EventSink получает события из outbox. Send вызывается в транзакции
+// публикации, и приемник может записать в нее свои данные. Событие считается
+// доставленным, когда его приняли все приемники; при ошибке любого из них
+// доставка повторяется всем, поэтому приемники должны учитывать event.ID
+type EventSink interface {
+	Name() string
+	Send(ctx context.Context, event *models.WebhookEvent) error
+}
+
+// OutboxDispatcherConfig описывает параметры публикации событий из outbox
+type OutboxDispatcherConfig struct {
+	// PollInterval - пауза между проверками outbox
+	PollInterval time.Duration
+	// BatchSize - сколько событий публикуется за одну проверку
+	BatchSize int
+	// MaxAttempts - после стольких неудач событие больше не публикуется
+	MaxAttempts int
+}
+
+// OutboxDispatcher публикует события из outbox в приемники
+type OutboxDispatcher struct {
+	outboxRepo repository.OutboxRepository
+	tx         repository.Transactor
+	sinks      []EventSink
+	cfg        OutboxDispatcherConfig
+}
+
+// NewOutboxDispatcher создает диспетчер outbox. Публикация начинается после вызова Run
+func NewOutboxDispatcher(
+	outboxRepo repository.OutboxRepository,
+	tx repository.Transactor,
+	sinks []EventSink,
+	cfg OutboxDispatcherConfig,
+) *OutboxDispatcher {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+
+	return &OutboxDispatcher{
+		outboxRepo: outboxRepo,
+		tx:         tx,
+		sinks:      sinks,
+		cfg:        cfg,
+	}
+}
+
+// Run публикует события, пока не будет отменен ctx
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Полная пачка означает, что в outbox могут остаться события
+		for {
+			processed, err := d.dispatchBatch(ctx)
+			if err != nil {
+				log.Printf("Failed to dispatch outbox events: %v", err)
+			}
+			if err != nil || processed < d.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchBatch публикует одну пачку событий и возвращает их число.
+// Строки заблокированы на время публикации, поэтому событие
+// не будет опубликовано параллельно другим экземпляром сервиса
+func (d *OutboxDispatcher) dispatchBatch(ctx context.Context) (int, error) {
+	processed := 0
+	err := d.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		entries, err := d.outboxRepo.FetchPending(ctx, d.cfg.BatchSize, d.cfg.MaxAttempts)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if err := d.send(ctx, &entry.Event); err != nil {
+				if entry.Attempts+1 >= d.cfg.MaxAttempts {
+					log.Printf("Giving up on outbox event %s after %d attempts: %v", entry.Event.ID, entry.Attempts+1, err)
+				}
+				if err := d.outboxRepo.MarkFailed(ctx, entry.ID, err.Error()); err != nil {
+					return err
+				}
+			} else if err := d.outboxRepo.MarkDelivered(ctx, entry.ID); err != nil {
+				return err
+			}
+		}
+
+		processed = len(entries)
+		return nil
+	})
+	return processed, err
+}
+
+// send передает событие всем приемникам и объединяет их ошибки
+func (d *OutboxDispatcher) send(ctx context.Context, event *models.WebhookEvent) error {
+	var failures []string
+	for _, sink := range d.sinks {
+		if err := sink.Send(ctx, event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sink.Name(), err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("sinks failed: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// LogSink выводит события в лог сервиса
+type LogSink struct{}
+
+// Name реализует EventSink
+func (LogSink) Name() string {
+	return "log"
+}
+
+// Send реализует EventSink
+func (LogSink) Send(_ context.Context, event *models.WebhookEvent) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	log.Printf("Event %s %s: %s", event.Type, event.ID, data)
+	return nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// fakeOutboxEntry - событие outbox в памяти
+type fakeOutboxEntry struct {
+	entry     models.OutboxEntry
+	delivered bool
+	lastError string
+}
+
+// fakeOutboxRepository хранит события outbox в памяти
+type fakeOutboxRepository struct {
+	repository.OutboxRepository
+	entries []*fakeOutboxEntry
+}
+
+func (r *fakeOutboxRepository) Add(_ context.Context, event *models.WebhookEvent) error {
+	r.entries = append(r.entries, &fakeOutboxEntry{
+		entry: models.OutboxEntry{ID: int64(len(r.entries) + 1), Event: *event},
+	})
+	return nil
+}
+
+func (r *fakeOutboxRepository) FetchPending(_ context.Context, limit, maxAttempts int) ([]*models.OutboxEntry, error) {
+	entries := []*models.OutboxEntry{}
+	for _, stored := range r.entries {
+		if stored.delivered || stored.entry.Attempts >= maxAttempts || len(entries) == limit {
+			continue
+		}
+		entry := stored.entry
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+func (r *fakeOutboxRepository) MarkDelivered(_ context.Context, id int64) error {
+	r.entries[id-1].delivered = true
+	return nil
+}
+
+func (r *fakeOutboxRepository) MarkFailed(_ context.Context, id int64, reason string) error {
+	r.entries[id-1].entry.Attempts++
+	r.entries[id-1].lastError = reason
+	return nil
+}
+
+// fakeEventSink запоминает ID полученных событий и возвращает err
+type fakeEventSink struct {
+	name     string
+	err      error
+	received []string
+}
+
+func (s *fakeEventSink) Name() string {
+	return s.name
+}
+
+func (s *fakeEventSink) Send(_ context.Context, event *models.WebhookEvent) error {
+	s.received = append(s.received, event.ID)
+	return s.err
+}
+
+// newOutbox создает outbox с событиями с заданными ID
+func newOutbox(t *testing.T, ids ...string) *fakeOutboxRepository {
+	t.Helper()
+
+	repo := &fakeOutboxRepository{}
+	for _, id := range ids {
+		if err := repo.Add(context.Background(), &models.WebhookEvent{ID: id, Type: models.EventPRCreated}); err != nil {
+			t.Fatalf("failed to add event: %v", err)
+		}
+	}
+	return repo
+}
+
+func TestOutboxDispatcherDelivers(t *testing.T) {
+	repo := newOutbox(t, "e1", "e2", "e3")
+	logSink := &fakeEventSink{name: "log"}
+	webhooks := &fakeEventSink{name: "webhooks"}
+	dispatcher := NewOutboxDispatcher(repo, passthroughTransactor{}, []EventSink{logSink, webhooks}, OutboxDispatcherConfig{BatchSize: 2})
+
+	for _, want := range []int{2, 1, 0} {
+		processed, err := dispatcher.dispatchBatch(context.Background())
+		if err != nil {
+			t.Fatalf("dispatchBatch() error = %v", err)
+		}
+		if processed != want {
+			t.Errorf("processed %d events, want %d", processed, want)
+		}
+	}
+
+	// Каждый приемник получает каждое событие один раз в порядке outbox
+	want := []string{"e1", "e2", "e3"}
+	for _, sink := range []*fakeEventSink{logSink, webhooks} {
+		if !reflect.DeepEqual(sink.received, want) {
+			t.Errorf("%s received %v, want %v", sink.name, sink.received, want)
+		}
+	}
+	for _, stored := range repo.entries {
+		if !stored.delivered || stored.entry.Attempts != 0 {
+			t.Errorf("event %s: delivered %v after %d failures, want delivered without failures",
+				stored.entry.Event.ID, stored.delivered, stored.entry.Attempts)
+		}
+	}
+}
+
+func TestOutboxDispatcherFailure(t *testing.T) {
+	repo := newOutbox(t, "e1")
+	logSink := &fakeEventSink{name: "log"}
+	webhooks := &fakeEventSink{name: "webhooks", err: errors.New("connection refused")}
+	dispatcher := NewOutboxDispatcher(repo, passthroughTransactor{}, []EventSink{logSink, webhooks}, OutboxDispatcherConfig{MaxAttempts: 2})
+
+	// Ошибка одного приемника не останавливает пачку и не откатывает
+	// отметку о неудаче, событие публикуется повторно до MaxAttempts
+	for _, want := range []int{1, 1, 0} {
+		processed, err := dispatcher.dispatchBatch(context.Background())
+		if err != nil {
+			t.Fatalf("dispatchBatch() error = %v", err)
+		}
+		if processed != want {
+			t.Errorf("processed %d events, want %d", processed, want)
+		}
+	}
+
+	stored := repo.entries[0]
+	if stored.delivered || stored.entry.Attempts != 2 {
+		t.Errorf("event: delivered %v after %d failures, want undelivered after 2", stored.delivered, stored.entry.Attempts)
+	}
+	if want := "sinks failed: webhooks: connection refused"; stored.lastError != want {
+		t.Errorf("last error = %q, want %q", stored.lastError, want)
+	}
+
+	// Повтор получают все приемники, включая принявший событие
+	want := []string{"e1", "e1"}
+	for _, sink := range []*fakeEventSink{logSink, webhooks} {
+		if !reflect.DeepEqual(sink.received, want) {
+			t.Errorf("%s received %v, want %v", sink.name, sink.received, want)
+		}
+	}
+}
+
+func TestOutboxPublisherUsesServiceClock(t *testing.T) {
+	repo := &fakeOutboxRepository{}
+	publisher := NewOutboxPublisher(repo)
+
+	clock := ClockFunc(func() time.Time { return testNow })
+	if err := emit(context.Background(), publisher, clock, models.EventPRMerged, map[string]string{"id": "pr-1"}); err != nil {
+		t.Fatalf("emit() error = %v", err)
+	}
+
+	if len(repo.entries) != 1 {
+		t.Fatalf("outbox has %d events, want 1", len(repo.entries))
+	}
+	event := repo.entries[0].entry.Event
+	if event.ID == "" || event.Type != models.EventPRMerged || !event.OccurredAt.Equal(testNow) {
+		t.Errorf("event = %+v, want %s with an ID at %v", event, models.EventPRMerged, testNow)
+	}
+}
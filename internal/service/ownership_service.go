@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// OwnershipService определяет интерфейс для управления правилами владения кодом
+type OwnershipService interface {
+	CreateRule(ctx context.Context, rule *models.OwnershipRule) (*models.OwnershipRule, error)
+	UpdateRule(ctx context.Context, rule *models.OwnershipRule) (*models.OwnershipRule, error)
+	DeleteRule(ctx context.Context, id int64) error
+	ListRules(ctx context.Context) ([]*models.OwnershipRule, error)
+}
+
+// ownershipService реализует OwnershipService
+type ownershipService struct {
+	ownershipRepo repository.OwnershipRepository
+	userRepo      repository.UserRepository
+	teamRepo      repository.TeamRepository
+}
+
+// NewOwnershipService создает новый сервис правил владения кодом
+func NewOwnershipService(
+	ownershipRepo repository.OwnershipRepository,
+	userRepo repository.UserRepository,
+	teamRepo repository.TeamRepository,
+) OwnershipService {
+	return &ownershipService{
+		ownershipRepo: ownershipRepo,
+		userRepo:      userRepo,
+		teamRepo:      teamRepo,
+	}
+}
+
+// CreateRule проверяет и создает правило. Новое правило применяется последним
+func (s *ownershipService) CreateRule(ctx context.Context, rule *models.OwnershipRule) (*models.OwnershipRule, error) {
+	if err := s.validateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.ownershipRepo.CreateRule(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to create ownership rule: %w", err)
+	}
+	return rule, nil
+}
+
+// UpdateRule проверяет и заменяет шаблон и владельцев правила, сохраняя его порядок
+func (s *ownershipService) UpdateRule(ctx context.Context, rule *models.OwnershipRule) (*models.OwnershipRule, error) {
+	if err := s.validateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.ownershipRepo.UpdateRule(ctx, rule); err != nil {
+		return nil, ErrOwnershipRuleNotFound
+	}
+	return rule, nil
+}
+
+// DeleteRule удаляет правило
+func (s *ownershipService) DeleteRule(ctx context.Context, id int64) error {
+	if err := s.ownershipRepo.DeleteRule(ctx, id); err != nil {
+		return ErrOwnershipRuleNotFound
+	}
+	return nil
+}
+
+// ListRules возвращает правила в порядке применения
+func (s *ownershipService) ListRules(ctx context.Context) ([]*models.OwnershipRule, error) {
+	rules, err := s.ownershipRepo.ListRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ownership rules: %w", err)
+	}
+	return rules, nil
+}
+
+// validateRule проверяет шаблон и что владельцы существуют и не повторяются
+func (s *ownershipService) validateRule(ctx context.Context, rule *models.OwnershipRule) error {
+	if !validOwnershipPattern(rule.Pattern) {
+		return ErrInvalidOwnershipRule
+	}
+	if len(rule.Users) == 0 && len(rule.Teams) == 0 {
+		return ErrInvalidOwnershipRule
+	}
+	if rule.Users == nil {
+		rule.Users = []string{}
+	}
+	if rule.Teams == nil {
+		rule.Teams = []string{}
+	}
+
+	seen := make(map[string]bool, len(rule.Users))
+	for _, userID := range rule.Users {
+		if seen[userID] {
+			return ErrInvalidOwnershipRule
+		}
+		seen[userID] = true
+		if _, err := s.userRepo.Get(ctx, userID); err != nil {
+			return ErrUserNotFound
+		}
+	}
+
+	seen = make(map[string]bool, len(rule.Teams))
+	for _, team := range rule.Teams {
+		if seen[team] {
+			return ErrInvalidOwnershipRule
+		}
+		seen[team] = true
+		exists, err := s.teamRepo.Exists(ctx, team)
+		if err != nil {
+			return fmt.Errorf("failed to check team existence: %w", err)
+		}
+		if !exists {
+			return ErrTeamNotFound
+		}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// PolicySet хранит политики назначения ревьюверов по командам.
+// Пустой набор (и nil) означает, что политик нет
+type PolicySet struct {
+	policies map[string]*models.TeamPolicy
+}
+
+// NewPolicySet проверяет политики и собирает их в набор. На команду
+// допускается одна политика
+func NewPolicySet(policies []*models.TeamPolicy) (*PolicySet, error) {
+	set := &PolicySet{policies: make(map[string]*models.TeamPolicy, len(policies))}
+	for _, policy := range policies {
+		if problems := policyProblems(policy); len(problems) > 0 {
+			return nil, fmt.Errorf("%w: team %q: %s", ErrInvalidPolicy, policy.Team, strings.Join(problems, "; "))
+		}
+		if _, ok := set.policies[policy.Team]; ok {
+			return nil, fmt.Errorf("%w: duplicate policy for team %q", ErrInvalidPolicy, policy.Team)
+		}
+		set.policies[policy.Team] = policy
+	}
+	return set, nil
+}
+
+// LoadPolicies читает политики из *.json файлов каталога dir. Файл, который не
+// удалось прочитать или который не прошел проверку, пропускается, и его ошибка
+// попадает в skipped: одна опечатка не должна останавливать сервис. Если две
+// политики относятся к одной команде, пропускаются обе, так как неясно, какая
+// из них верна. Файлы *.yaml и *.yml не поддерживаются и тоже попадают в
+// skipped, чтобы не игнорироваться молча. Пустой dir - политик нет
+func LoadPolicies(dir string) (*PolicySet, []error, error) {
+	if dir == "" {
+		set, err := NewPolicySet(nil)
+		return set, nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list policy files: %w", err)
+	}
+
+	var skipped []error
+	files := make(map[string][]string)
+	byTeam := make(map[string]*models.TeamPolicy)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		switch filepath.Ext(name) {
+		case ".json":
+		case ".yaml", ".yml":
+			skipped = append(skipped, fmt.Errorf("%s: %w: only JSON policies are supported", name, ErrInvalidPolicy))
+			continue
+		default:
+			continue
+		}
+
+		policy, err := loadPolicyFile(filepath.Join(dir, name))
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		files[policy.Team] = append(files[policy.Team], name)
+		byTeam[policy.Team] = policy
+	}
+
+	policies := make([]*models.TeamPolicy, 0, len(byTeam))
+	for team, policy := range byTeam {
+		if names := files[team]; len(names) > 1 {
+			skipped = append(skipped, fmt.Errorf("%s: %w: duplicate policy for team %q", strings.Join(names, ", "), ErrInvalidPolicy, team))
+			continue
+		}
+		policies = append(policies, policy)
+	}
+	// Порядок ошибок не зависит от обхода map
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i].Error() < skipped[j].Error()
+	})
+
+	set, err := NewPolicySet(policies)
+	return set, skipped, err
+}
+
+// loadPolicyFile читает и проверяет политику из файла без обращения к БД
+func loadPolicyFile(path string) (*models.TeamPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return nil, err
+	}
+	if problems := policyProblems(policy); len(problems) > 0 {
+		return nil, fmt.Errorf("%w: team %q: %s", ErrInvalidPolicy, policy.Team, strings.Join(problems, "; "))
+	}
+	return policy, nil
+}
+
+// ParsePolicy разбирает JSON-документ политики. Неизвестные поля считаются
+// ошибкой, чтобы опечатки не игнорировались молча
+func ParsePolicy(data []byte) (*models.TeamPolicy, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var policy models.TeamPolicy
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("%w: unexpected data after policy document", ErrInvalidPolicy)
+	}
+	return &policy, nil
+}
+
+// ForTeam возвращает политику команды или nil
+func (s *PolicySet) ForTeam(team string) *models.TeamPolicy {
+	if s == nil {
+		return nil
+	}
+	return s.policies[team]
+}
+
+// Policies возвращает все политики в порядке имен команд
+func (s *PolicySet) Policies() []*models.TeamPolicy {
+	if s == nil {
+		return nil
+	}
+
+	policies := make([]*models.TeamPolicy, 0, len(s.policies))
+	for _, policy := range s.policies {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Team < policies[j].Team
+	})
+	return policies
+}
+
+// Strategies дополняет стратегии команд из конфигурации стратегиями из
+// политик. Стратегия из политики имеет приоритет
+func (s *PolicySet) Strategies(base map[string]string) map[string]string {
+	strategies := make(map[string]string, len(base))
+	for team, name := range base {
+		strategies[team] = name
+	}
+	for _, policy := range s.Policies() {
+		if policy.Strategy != "" {
+			strategies[policy.Team] = policy.Strategy
+		}
+	}
+	return strategies
+}
+
+// policyProblems проверяет политику без обращения к БД и возвращает
+// найденные проблемы
+func policyProblems(policy *models.TeamPolicy) []string {
+	var problems []string
+	if policy.Team == "" {
+		problems = append(problems, "team is required")
+	}
+	if policy.MinReviewers != nil && *policy.MinReviewers < 0 {
+		problems = append(problems, "min_reviewers must not be negative")
+	}
+	if policy.MaxReviewers != nil && (*policy.MaxReviewers < 0 || *policy.MaxReviewers > maxReviewersLimit) {
+		problems = append(problems, fmt.Sprintf("max_reviewers must be between 0 and %d", maxReviewersLimit))
+	}
+	if policy.RequiredApprovals != nil && *policy.RequiredApprovals < 0 {
+		problems = append(problems, "required_approvals must not be negative")
+	}
+	if policy.Strategy != "" && !knownStrategy(policy.Strategy) {
+		problems = append(problems, fmt.Sprintf("unknown strategy %q", policy.Strategy))
+	}
+	if req := policy.RequiredSeniority; req != nil {
+		if !validSeniority(req.Level) {
+			problems = append(problems, fmt.Sprintf("unknown seniority level %q", req.Level))
+		}
+		if req.Count < 1 {
+			problems = append(problems, "required_seniority.count must be positive")
+		}
+		if policy.MaxReviewers != nil && req.Count > *policy.MaxReviewers {
+			problems = append(problems, "required_seniority.count must not exceed max_reviewers")
+		}
+	}
+
+	seen := make(map[string]bool, len(policy.FallbackTeams))
+	for _, team := range policy.FallbackTeams {
+		switch {
+		case team == "":
+			problems = append(problems, "fallback_teams must not contain empty names")
+		case team == policy.Team:
+			problems = append(problems, "fallback_teams must not contain the team itself")
+		case seen[team]:
+			problems = append(problems, fmt.Sprintf("fallback team %q is listed twice", team))
+		}
+		seen[team] = true
+	}
+
+	seen = make(map[string]bool, len(policy.ExcludeUsers))
+	for _, userID := range policy.ExcludeUsers {
+		switch {
+		case userID == "":
+			problems = append(problems, "exclude_users must not contain empty ids")
+		case seen[userID]:
+			problems = append(problems, fmt.Sprintf("excluded user %q is listed twice", userID))
+		}
+		seen[userID] = true
+	}
+	return problems
+}
+
+// applyPolicy возвращает настройки команды с учетом ее политики
+func applyPolicy(settings *models.TeamSettings, policy *models.TeamPolicy) *models.TeamSettings {
+	if policy == nil {
+		return settings
+	}
+
+	effective := *settings
+	if policy.MinReviewers != nil {
+		effective.MinReviewers = *policy.MinReviewers
+	}
+	if policy.MaxReviewers != nil {
+		effective.MaxReviewers = *policy.MaxReviewers
+	}
+	if policy.RequiredApprovals != nil {
+		effective.RequiredApprovals = *policy.RequiredApprovals
+	}
+	if policy.PreferWorkingHours != nil {
+		effective.PreferWorkingHours = *policy.PreferWorkingHours
+	}
+	if policy.RequiredSeniority != nil {
+		effective.RequiredSeniority = policy.RequiredSeniority.Level
+		effective.RequiredSeniorityCount = policy.RequiredSeniority.Count
+	}
+	return &effective
+}
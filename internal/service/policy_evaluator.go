@@ -0,0 +1,120 @@
+package service
+
+import "github.com/zazaza5818/pr-reviewer-service/internal/models"
+
+// policyEvaluator проверяет кандидатов в ревьюверы по правилам назначения
+// и исключениям из политик команд. Один и тот же evaluator используется при
+// создании PR, выходе из черновика и переназначении
+type policyEvaluator struct {
+	policies *PolicySet
+	// excludes - правила EXCLUDE по ID ревьювера
+	excludes map[string][]*models.ReviewerRule
+	// pairs - правила PAIR по ID ревьювера, которому нужен наставник
+	pairs map[string][]*models.ReviewerRule
+}
+
+// newPolicyEvaluator индексирует правила по ревьюверу
+func newPolicyEvaluator(rules []*models.ReviewerRule, policies *PolicySet) *policyEvaluator {
+	p := &policyEvaluator{
+		policies: policies,
+		excludes: make(map[string][]*models.ReviewerRule),
+		pairs:    make(map[string][]*models.ReviewerRule),
+	}
+	for _, rule := range rules {
+		switch rule.Kind {
+		case models.RuleExclude:
+			p.excludes[rule.UserID] = append(p.excludes[rule.UserID], rule)
+		case models.RulePair:
+			p.pairs[rule.UserID] = append(p.pairs[rule.UserID], rule)
+		}
+	}
+	return p
+}
+
+// check проверяет, можно ли назначить candidate на PR автора authorID из
+// команды team, если ревьюверами уже являются reviewers. Возвращает первое
+// нарушенное правило или nil. Кандидату с правилами PAIR достаточно любого
+// из своих наставников
+func (p *policyEvaluator) check(candidateID, authorID, team string, reviewers map[string]bool) *models.RuleExclusion {
+	if policy := p.policies.ForTeam(team); policy != nil {
+		for _, userID := range policy.ExcludeUsers {
+			if userID == candidateID {
+				return &models.RuleExclusion{
+					Kind:   models.RulePolicy,
+					UserID: candidateID,
+					Reason: "excluded by policy of team " + team,
+				}
+			}
+		}
+	}
+
+	for _, rule := range p.excludes[candidateID] {
+		if rule.TargetID == authorID {
+			return &models.RuleExclusion{
+				RuleID: rule.ID,
+				Kind:   rule.Kind,
+				UserID: candidateID,
+				Reason: "never reviews pull requests of " + authorID,
+			}
+		}
+	}
+
+	pairs := p.pairs[candidateID]
+	if len(pairs) == 0 {
+		return nil
+	}
+	for _, rule := range pairs {
+		if reviewers[rule.TargetID] {
+			return nil
+		}
+	}
+	return &models.RuleExclusion{
+		RuleID: pairs[0].ID,
+		Kind:   pairs[0].Kind,
+		UserID: candidateID,
+		Reason: "must be paired with mentor " + pairs[0].TargetID,
+	}
+}
+
+// replacementMentors возвращает пользователей, среди которых нужно выбрать
+// замену, чтобы никто из оставшихся ревьюверов remaining не остался без
+// наставника. nil - замена может быть любой, пустое множество - подходящей
+// замены нет
+func (p *policyEvaluator) replacementMentors(remaining []string) map[string]bool {
+	reviewers := make(map[string]bool, len(remaining))
+	for _, reviewerID := range remaining {
+		reviewers[reviewerID] = true
+	}
+
+	var allowed map[string]bool
+	for _, reviewerID := range remaining {
+		pairs := p.pairs[reviewerID]
+		if len(pairs) == 0 {
+			continue
+		}
+
+		mentors := make(map[string]bool, len(pairs))
+		paired := false
+		for _, rule := range pairs {
+			if reviewers[rule.TargetID] {
+				paired = true
+				break
+			}
+			mentors[rule.TargetID] = true
+		}
+		if paired {
+			continue
+		}
+
+		if allowed == nil {
+			allowed = mentors
+			continue
+		}
+		for userID := range allowed {
+			if !mentors[userID] {
+				delete(allowed, userID)
+			}
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// evaluatorRules - правила назначения для тестов policyEvaluator:
+// alice не ревьюит PR автора bob, junior работает только с senior или lead
+var evaluatorRules = []*models.ReviewerRule{
+	{ID: 1, Kind: models.RuleExclude, UserID: "alice", TargetID: "bob"},
+	{ID: 2, Kind: models.RulePair, UserID: "junior", TargetID: "senior"},
+	{ID: 3, Kind: models.RulePair, UserID: "junior", TargetID: "lead"},
+	{ID: 4, Kind: models.RulePair, UserID: "intern", TargetID: "lead"},
+}
+
+func TestPolicyEvaluatorCheck(t *testing.T) {
+	p := newPolicyEvaluator(evaluatorRules, nil)
+
+	tests := []struct {
+		name      string
+		candidate string
+		author    string
+		reviewers []string
+		want      *models.RuleExclusion
+	}{
+		{name: "no rules", candidate: "carol", author: "bob"},
+		{
+			name:      "excluded author",
+			candidate: "alice",
+			author:    "bob",
+			want:      &models.RuleExclusion{RuleID: 1, Kind: models.RuleExclude, UserID: "alice", Reason: "never reviews pull requests of bob"},
+		},
+		{name: "other author", candidate: "alice", author: "carol"},
+		{
+			name:      "mentor not assigned",
+			candidate: "junior",
+			author:    "bob",
+			reviewers: []string{"carol"},
+			want:      &models.RuleExclusion{RuleID: 2, Kind: models.RulePair, UserID: "junior", Reason: "must be paired with mentor senior"},
+		},
+		{name: "first mentor assigned", candidate: "junior", author: "bob", reviewers: []string{"senior"}},
+		{name: "any mentor is enough", candidate: "junior", author: "bob", reviewers: []string{"carol", "lead"}},
+		// Правило PAIR связывает ревьюверов, а не автора
+		{
+			name:      "mentor is the author",
+			candidate: "intern",
+			author:    "lead",
+			want:      &models.RuleExclusion{RuleID: 4, Kind: models.RulePair, UserID: "intern", Reason: "must be paired with mentor lead"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reviewers := make(map[string]bool, len(tt.reviewers))
+			for _, reviewerID := range tt.reviewers {
+				reviewers[reviewerID] = true
+			}
+
+			got := p.check(tt.candidate, tt.author, "backend", reviewers)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("check() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyEvaluatorReplacementMentors(t *testing.T) {
+	p := newPolicyEvaluator(evaluatorRules, nil)
+
+	tests := []struct {
+		name      string
+		remaining []string
+		want      map[string]bool
+	}{
+		{name: "no pairs", remaining: []string{"alice", "carol"}, want: nil},
+		{name: "mentor stays", remaining: []string{"junior", "lead"}, want: nil},
+		{name: "mentor leaves", remaining: []string{"junior"}, want: map[string]bool{"senior": true, "lead": true}},
+		{name: "common mentor", remaining: []string{"junior", "intern"}, want: map[string]bool{"lead": true}},
+		{name: "one of two paired", remaining: []string{"junior", "intern", "senior"}, want: map[string]bool{"lead": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.replacementMentors(tt.remaining)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("replacementMentors(%v) = %v, want %v", tt.remaining, got, tt.want)
+			}
+		})
+	}
+}
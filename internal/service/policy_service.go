@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// PolicyService определяет интерфейс для проверки политик назначения ревьюверов
+type PolicyService interface {
+	Validate(ctx context.Context, data []byte) (*models.PolicyValidation, error)
+	Check(ctx context.Context, policy *models.TeamPolicy) (*models.PolicyValidation, error)
+}
+
+// policyService реализует PolicyService
+type policyService struct {
+	teamRepo repository.TeamRepository
+	userRepo repository.UserRepository
+}
+
+// NewPolicyService создает новый сервис проверки политик
+func NewPolicyService(teamRepo repository.TeamRepository, userRepo repository.UserRepository) PolicyService {
+	return &policyService{
+		teamRepo: teamRepo,
+		userRepo: userRepo,
+	}
+}
+
+// Validate разбирает и проверяет документ политики, ничего не сохраняя.
+// Ошибки документа возвращаются как проблемы, а не как ошибка
+func (s *policyService) Validate(ctx context.Context, data []byte) (*models.PolicyValidation, error) {
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPolicy) {
+			return &models.PolicyValidation{Problems: []string{err.Error()}}, nil
+		}
+		return nil, err
+	}
+	return s.Check(ctx, policy)
+}
+
+// Check проверяет политику вместе с данными в БД: команды и пользователи
+// должны существовать, а настройки команды с учетом политики - быть
+// согласованными. Для корректной политики возвращает итоговые настройки
+func (s *policyService) Check(ctx context.Context, policy *models.TeamPolicy) (*models.PolicyValidation, error) {
+	result := &models.PolicyValidation{Problems: policyProblems(policy)}
+	if policy.Team == "" {
+		return result, nil
+	}
+
+	exists, err := s.teamRepo.Exists(ctx, policy.Team)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check team existence: %w", err)
+	}
+	if !exists {
+		result.Problems = append(result.Problems, fmt.Sprintf("team %q not found", policy.Team))
+		return result, nil
+	}
+
+	for _, team := range policy.FallbackTeams {
+		exists, err := s.teamRepo.Exists(ctx, team)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check team existence: %w", err)
+		}
+		if !exists {
+			result.Problems = append(result.Problems, fmt.Sprintf("fallback team %q not found", team))
+		}
+	}
+
+	for _, userID := range policy.ExcludeUsers {
+		if _, err := s.userRepo.Get(ctx, userID); err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("excluded user %q not found", userID))
+		}
+	}
+
+	settings, err := s.teamRepo.GetSettings(ctx, policy.Team)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team settings: %w", err)
+	}
+	effective := applyPolicy(settings, policy)
+	if validateSettings(effective) != nil {
+		result.Problems = append(result.Problems,
+			"with team settings expected min_reviewers <= max_reviewers, "+
+				"required_approvals and required_seniority.count <= max_reviewers")
+	}
+
+	if len(result.Problems) > 0 {
+		return result, nil
+	}
+
+	fallbacks := policy.FallbackTeams
+	if fallbacks == nil {
+		fallbacks, err = s.teamRepo.GetFallbackTeams(ctx, policy.Team)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get fallback teams: %w", err)
+		}
+	}
+
+	result.Valid = true
+	result.Problems = []string{}
+	result.Settings = effective
+	result.FallbackTeams = fallbacks
+	return result, nil
+}
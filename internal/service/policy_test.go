@@ -0,0 +1,197 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		wantErr  error
+	}{
+		{name: "valid", document: `{"team": "backend", "max_reviewers": 3, "strategy": "round_robin"}`},
+		// Опечатка в имени поля не игнорируется молча
+		{name: "unknown field", document: `{"team": "backend", "max_reviewer": 3}`, wantErr: ErrInvalidPolicy},
+		{name: "trailing data", document: `{"team": "backend"} {"team": "frontend"}`, wantErr: ErrInvalidPolicy},
+		{name: "not json", document: `team: backend`, wantErr: ErrInvalidPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePolicy([]byte(tt.document)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParsePolicy() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicyProblems(t *testing.T) {
+	two, negative := 2, -1
+	tests := []struct {
+		name   string
+		policy models.TeamPolicy
+		want   []string
+	}{
+		{
+			name:   "valid",
+			policy: models.TeamPolicy{Team: "backend", MaxReviewers: &two, Strategy: StrategyLeastLoaded},
+		},
+		{
+			name:   "missing team",
+			policy: models.TeamPolicy{},
+			want:   []string{"team is required"},
+		},
+		{
+			name:   "negative counts",
+			policy: models.TeamPolicy{Team: "backend", MinReviewers: &negative, RequiredApprovals: &negative},
+			want:   []string{"min_reviewers must not be negative", "required_approvals must not be negative"},
+		},
+		{
+			name:   "unknown strategy",
+			policy: models.TeamPolicy{Team: "backend", Strategy: "fastest"},
+			want:   []string{`unknown strategy "fastest"`},
+		},
+		{
+			// Требование к уровню не помещается в max_reviewers
+			name: "seniority count above max reviewers",
+			policy: models.TeamPolicy{
+				Team:              "backend",
+				MaxReviewers:      &two,
+				RequiredSeniority: &models.SeniorityRequirement{Level: models.SenioritySenior, Count: 3},
+			},
+			want: []string{"required_seniority.count must not exceed max_reviewers"},
+		},
+		{
+			name: "fallback and excluded duplicates",
+			policy: models.TeamPolicy{
+				Team:          "backend",
+				FallbackTeams: []string{"backend", "frontend", "frontend"},
+				ExcludeUsers:  []string{"alice", "alice"},
+			},
+			want: []string{
+				"fallback_teams must not contain the team itself",
+				`fallback team "frontend" is listed twice`,
+				`excluded user "alice" is listed twice`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policyProblems(&tt.policy); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("policyProblems() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPolicySetRejectsDuplicateTeam(t *testing.T) {
+	_, err := NewPolicySet([]*models.TeamPolicy{{Team: "backend"}, {Team: "backend"}})
+	if !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("NewPolicySet() error = %v, want %v", err, ErrInvalidPolicy)
+	}
+}
+
+func TestPolicyStrategies(t *testing.T) {
+	policies, err := NewPolicySet([]*models.TeamPolicy{
+		{Team: "backend", Strategy: StrategyRoundRobin},
+		{Team: "mobile"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create policies: %v", err)
+	}
+
+	// Стратегия из политики важнее стратегии из конфигурации, команды
+	// без стратегии в политике сохраняют стратегию из конфигурации
+	got := policies.Strategies(map[string]string{
+		"backend":  StrategyLeastLoaded,
+		"mobile":   StrategyWeighted,
+		"frontend": StrategyRandom,
+	})
+	want := map[string]string{
+		"backend":  StrategyRoundRobin,
+		"mobile":   StrategyWeighted,
+		"frontend": StrategyRandom,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Strategies() = %v, want %v", got, want)
+	}
+}
+
+// writePolicyFiles создает в temp-каталоге файлы с заданным содержимым
+func writePolicyFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write policy file: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestLoadPolicies(t *testing.T) {
+	dir := writePolicyFiles(t, map[string]string{
+		"backend.json":   `{"team": "backend", "max_reviewers": 3}`,
+		"frontend.json":  `{"team": "frontend", "strategy": "fastest"}`,
+		"typo.json":      `{"team": "ops", "max_reviewer": 3}`,
+		"mobile-a.json":  `{"team": "mobile", "max_reviewers": 1}`,
+		"mobile-b.json":  `{"team": "mobile", "max_reviewers": 2}`,
+		"platform.yaml":  `team: platform`,
+		"README.md":      `policies`,
+		"data.json.bak":  `{}`,
+		"frontend2.json": `{"team": "frontend2", "required_seniority": {"level": "SENIOR", "count": 1}}`,
+	})
+
+	policies, skipped, err := LoadPolicies(dir)
+	if err != nil {
+		t.Fatalf("LoadPolicies() error = %v", err)
+	}
+
+	// Корректные политики загружаются, несмотря на ошибки в других файлах
+	var teams []string
+	for _, policy := range policies.Policies() {
+		teams = append(teams, policy.Team)
+	}
+	if want := []string{"backend", "frontend2"}; !reflect.DeepEqual(teams, want) {
+		t.Errorf("loaded policies of %v, want %v", teams, want)
+	}
+
+	// Пропущенные файлы перечислены в ошибках, и каждая ошибка - ErrInvalidPolicy
+	var messages []string
+	for _, err := range skipped {
+		if !errors.Is(err, ErrInvalidPolicy) {
+			t.Errorf("skipped error %v is not %v", err, ErrInvalidPolicy)
+		}
+		messages = append(messages, err.Error())
+	}
+	joined := strings.Join(messages, "\n")
+	for _, want := range []string{"frontend.json", "typo.json", "mobile-a.json, mobile-b.json", "platform.yaml"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("skipped errors %q do not mention %s", messages, want)
+		}
+	}
+	if len(skipped) != 4 {
+		t.Errorf("got %d skipped errors, want 4: %q", len(skipped), messages)
+	}
+}
+
+func TestLoadPoliciesWithoutDir(t *testing.T) {
+	policies, skipped, err := LoadPolicies("")
+	if err != nil || len(skipped) != 0 || len(policies.Policies()) != 0 {
+		t.Errorf("LoadPolicies(\"\") = %v, %v, %v, want no policies", policies.Policies(), skipped, err)
+	}
+
+	if _, _, err := LoadPolicies(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadPolicies() of missing dir error = nil, want error")
+	}
+}
@@ -2,9 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
-	"time"
+	"strings"
 
 	"github.com/zazaza5818/pr-reviewer-service/internal/models"
 	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
@@ -12,209 +12,1150 @@ import (
 
 // PullRequestService определяет интерфейс для работы с Pull Request
 type PullRequestService interface {
-	CreatePullRequest(ctx context.Context, prID, prName, authorID string) (*models.PullRequest, error)
-	MergePullRequest(ctx context.Context, prID string) (*models.PullRequest, error)
-	ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (*models.PullRequest, string, error)
+	CreatePullRequest(ctx context.Context, params CreatePullRequestParams) (*models.PullRequest, error)
+	MergePullRequest(ctx context.Context, prID string, force bool) (*models.PullRequest, error)
+	ClosePullRequest(ctx context.Context, prID string) (*models.PullRequest, error)
+	ReopenPullRequest(ctx context.Context, prID string) (*models.PullRequest, error)
+	MarkReady(ctx context.Context, prID string) (*models.PullRequest, error)
+	ReassignReviewer(ctx context.Context, prID, oldReviewerID, reason string) (*models.PullRequest, string, error)
+	ReassignOpenReviews(ctx context.Context, reviewerIDs []string, reason string) (*models.ReassignmentReport, error)
+	GetHistory(ctx context.Context, prID string) ([]*models.AssignmentEvent, error)
+	SubmitReview(ctx context.Context, prID, reviewerID string, decision models.ReviewDecision) (*models.Review, error)
+	SetTags(ctx context.Context, prID string, tags []string) (*models.PullRequest, error)
+}
+
+// CreatePullRequestParams содержит параметры создания PR
+type CreatePullRequestParams struct {
+	PullRequestID   string
+	PullRequestName string
+	AuthorID        string
+	// Draft создает черновик без ревьюверов
+	Draft bool
+	// ChangedFiles - пути, измененные в PR. Владельцы этих путей
+	// выбираются ревьюверами в первую очередь
+	ChangedFiles []string
+	// Tags - навыки, нужные для ревью PR
+	Tags []string
+}
+
+// allowedTransitions описывает допустимые переходы между статусами PR
+var allowedTransitions = map[models.PullRequestStatus][]models.PullRequestStatus{
+	models.StatusDraft:  {models.StatusOpen, models.StatusClosed},
+	models.StatusOpen:   {models.StatusMerged, models.StatusClosed},
+	models.StatusClosed: {models.StatusOpen},
+}
+
+// checkTransition проверяет, что PR может перейти из статуса from в статус to
+func checkTransition(from, to models.PullRequestStatus) error {
+	for _, allowed := range allowedTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	if from == models.StatusMerged {
+		return ErrPRMerged
+	}
+	return ErrInvalidStatus
 }
 
 // pullRequestService реализует PullRequestService
 type pullRequestService struct {
-	userRepo repository.UserRepository
-	prRepo   repository.PullRequestRepository
-	rand     *rand.Rand
+	userRepo      repository.UserRepository
+	prRepo        repository.PullRequestRepository
+	teamRepo      repository.TeamRepository
+	eventRepo     repository.AssignmentEventRepository
+	ownershipRepo repository.OwnershipRepository
+	ruleRepo      repository.RuleRepository
+	tx            repository.Transactor
+	selectors     *SelectorRegistry
+	policies      *PolicySet
+	events        EventPublisher
+	clock         Clock
 }
 
 // NewPullRequestService создает новый сервис для работы с Pull Request
 func NewPullRequestService(
 	userRepo repository.UserRepository,
 	prRepo repository.PullRequestRepository,
+	teamRepo repository.TeamRepository,
+	eventRepo repository.AssignmentEventRepository,
+	ownershipRepo repository.OwnershipRepository,
+	ruleRepo repository.RuleRepository,
+	tx repository.Transactor,
+	selectors *SelectorRegistry,
+	policies *PolicySet,
+	events EventPublisher,
+	clock Clock,
 ) PullRequestService {
 	return &pullRequestService{
-		userRepo: userRepo,
-		prRepo:   prRepo,
-		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		userRepo:      userRepo,
+		prRepo:        prRepo,
+		teamRepo:      teamRepo,
+		eventRepo:     eventRepo,
+		ownershipRepo: ownershipRepo,
+		ruleRepo:      ruleRepo,
+		tx:            tx,
+		selectors:     selectors,
+		policies:      policies,
+		events:        events,
+		clock:         clock,
 	}
 }
 
-// CreatePullRequest создает PR и автоматически назначает ревьюверов
-func (s *pullRequestService) CreatePullRequest(ctx context.Context, prID, prName, authorID string) (*models.PullRequest, error) {
-	// Проверяем существование PR
-	exists, err := s.prRepo.Exists(ctx, prID)
+// CreatePullRequest создает PR и автоматически назначает ревьюверов.
+// Черновик создается без ревьюверов, они назначаются в MarkReady
+func (s *pullRequestService) CreatePullRequest(ctx context.Context, params CreatePullRequestParams) (*models.PullRequest, error) {
+	// Повторное создание отклоняет первичный ключ в prRepo.Create
+	tags, err := normalizeTags(params.Tags)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check PR existence: %w", err)
-	}
-	if exists {
-		return nil, ErrPRExists
+		return nil, err
 	}
 
 	// Получаем автора
-	author, err := s.userRepo.Get(ctx, authorID)
+	author, err := s.userRepo.Get(ctx, params.AuthorID)
 	if err != nil {
 		return nil, ErrUserNotFound
 	}
 
-	// Получаем активных участников команды автора (исключая самого автора)
-	candidates, err := s.userRepo.GetActiveTeammates(ctx, author.TeamName, authorID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get team candidates: %w", err)
+	status := models.StatusDraft
+	reviewers := []string{}
+	var choices []models.ReviewerChoice
+	var excluded []models.RuleExclusion
+	if !params.Draft {
+		status = models.StatusOpen
+		choices, excluded, err = s.initialReviewers(ctx, author, params.ChangedFiles, tags)
+		if err != nil {
+			return nil, err
+		}
+		reviewers = reviewerIDs(choices)
 	}
 
-	// Выбираем до 2 случайных ревьюверов
-	reviewers := s.selectRandomReviewers(candidates, 2)
-
 	// Создаем PR
 	pr := &models.PullRequest{
-		PullRequestID:     prID,
-		PullRequestName:   prName,
-		AuthorID:          authorID,
-		Status:            models.StatusOpen,
+		PullRequestID:     params.PullRequestID,
+		PullRequestName:   params.PullRequestName,
+		AuthorID:          params.AuthorID,
+		Status:            status,
 		AssignedReviewers: reviewers,
 	}
 
-	if err := s.prRepo.Create(ctx, pr); err != nil {
-		return nil, fmt.Errorf("failed to create PR: %w", err)
-	}
+	// PR, назначения, история и события записываются в одной транзакции
+	var createdPR *models.PullRequest
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.prRepo.Create(ctx, pr); err != nil {
+			if errors.Is(err, repository.ErrPRExists) {
+				return ErrPRExists
+			}
+			return fmt.Errorf("failed to create PR: %w", err)
+		}
+		if len(params.ChangedFiles) > 0 {
+			if err := s.prRepo.SetChangedFiles(ctx, pr.PullRequestID, params.ChangedFiles); err != nil {
+				return err
+			}
+		}
+		if len(tags) > 0 {
+			if err := s.prRepo.SetTags(ctx, pr.PullRequestID, tags); err != nil {
+				return err
+			}
+		}
+		if err := s.recordAssigned(ctx, pr.PullRequestID, reviewers, "pull request created"); err != nil {
+			return err
+		}
 
-	// Получаем созданный PR с полными данными
-	createdPR, err := s.prRepo.Get(ctx, prID)
+		// Получаем созданный PR с полными данными
+		createdPR, err = s.prRepo.Get(ctx, params.PullRequestID)
+		if err != nil {
+			return fmt.Errorf("failed to get created PR: %w", err)
+		}
+		createdPR.Selection = choices
+		createdPR.Excluded = excluded
+
+		if err := emit(ctx, s.events, s.clock, models.EventPRCreated, map[string]interface{}{"pr": createdPR}); err != nil {
+			return err
+		}
+		return s.emitAssigned(ctx, createdPR.PullRequestID, reviewers)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get created PR: %w", err)
+		return nil, err
 	}
 
 	return createdPR, nil
 }
 
-// MergePullRequest помечает PR как MERGED (идемпотентная операция)
-func (s *pullRequestService) MergePullRequest(ctx context.Context, prID string) (*models.PullRequest, error) {
-	pr, err := s.prRepo.Get(ctx, prID)
+// MergePullRequest помечает PR как MERGED (идемпотентная операция).
+// Без force требуется required_approvals одобрений от ревьюверов.
+// Переходы статуса выполняются в транзакции с блокировкой строки PR,
+// поэтому параллельные переходы одного PR выполняются по очереди
+func (s *pullRequestService) MergePullRequest(ctx context.Context, prID string, force bool) (*models.PullRequest, error) {
+	var mergedPR *models.PullRequest
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		pr, err := s.lockPullRequest(ctx, prID)
+		if err != nil {
+			return err
+		}
+
+		// Если уже merged, просто возвращаем PR (идемпотентность)
+		if pr.Status == models.StatusMerged {
+			mergedPR = pr
+			return nil
+		}
+
+		if err := checkTransition(pr.Status, models.StatusMerged); err != nil {
+			return err
+		}
+
+		if !force {
+			if err := s.checkApprovals(ctx, pr); err != nil {
+				return err
+			}
+		}
+
+		// Обновляем статус
+		pr.Status = models.StatusMerged
+		now := s.clock.Now()
+		pr.MergedAt = &now
+
+		reason := ""
+		if force {
+			reason = "forced merge"
+		}
+
+		if err := s.prRepo.Update(ctx, pr); err != nil {
+			return fmt.Errorf("failed to merge PR: %w", err)
+		}
+		err = s.recordEvent(ctx, &models.AssignmentEvent{
+			PullRequestID: pr.PullRequestID,
+			EventType:     models.AssignmentEventMerged,
+			Reason:        reason,
+		})
+		if err != nil {
+			return err
+		}
+		mergedPR = pr
+		return emit(ctx, s.events, s.clock, models.EventPRMerged, map[string]interface{}{"pr": pr})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergedPR, nil
+}
+
+// ClosePullRequest помечает PR как CLOSED без слияния (идемпотентная операция)
+func (s *pullRequestService) ClosePullRequest(ctx context.Context, prID string) (*models.PullRequest, error) {
+	var closedPR *models.PullRequest
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		pr, err := s.lockPullRequest(ctx, prID)
+		if err != nil {
+			return err
+		}
+
+		if pr.Status == models.StatusClosed {
+			closedPR = pr
+			return nil
+		}
+
+		if err := checkTransition(pr.Status, models.StatusClosed); err != nil {
+			return err
+		}
+
+		pr.Status = models.StatusClosed
+		now := s.clock.Now()
+		pr.ClosedAt = &now
+
+		if err := s.prRepo.Update(ctx, pr); err != nil {
+			return fmt.Errorf("failed to close PR: %w", err)
+		}
+		err = s.recordEvent(ctx, &models.AssignmentEvent{
+			PullRequestID: pr.PullRequestID,
+			EventType:     models.AssignmentEventClosed,
+		})
+		if err != nil {
+			return err
+		}
+		closedPR = pr
+		return emit(ctx, s.events, s.clock, models.EventPRClosed, map[string]interface{}{"pr": pr})
+	})
 	if err != nil {
-		return nil, ErrPRNotFound
+		return nil, err
 	}
 
-	// Если уже merged, просто возвращаем PR (идемпотентность)
-	if pr.Status == models.StatusMerged {
-		return pr, nil
+	return closedPR, nil
+}
+
+// ReopenPullRequest возвращает закрытый PR в статус OPEN (идемпотентная операция).
+// Если у PR нет ревьюверов (например, он был закрыт черновиком), они назначаются заново
+func (s *pullRequestService) ReopenPullRequest(ctx context.Context, prID string) (*models.PullRequest, error) {
+	var openedPR *models.PullRequest
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		pr, err := s.lockPullRequest(ctx, prID)
+		if err != nil {
+			return err
+		}
+
+		if pr.Status == models.StatusOpen {
+			openedPR = pr
+			return nil
+		}
+
+		if pr.Status == models.StatusMerged {
+			return ErrPRMerged
+		}
+		if pr.Status != models.StatusClosed {
+			return ErrInvalidStatus
+		}
+
+		openedPR, err = s.openPullRequest(ctx, pr, "pull request reopened")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return openedPR, nil
+}
+
+// MarkReady переводит черновик в статус OPEN и назначает ревьюверов (идемпотентная операция)
+func (s *pullRequestService) MarkReady(ctx context.Context, prID string) (*models.PullRequest, error) {
+	var openedPR *models.PullRequest
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		pr, err := s.lockPullRequest(ctx, prID)
+		if err != nil {
+			return err
+		}
+
+		if pr.Status == models.StatusOpen {
+			openedPR = pr
+			return nil
+		}
+
+		if pr.Status == models.StatusMerged {
+			return ErrPRMerged
+		}
+		if pr.Status != models.StatusDraft {
+			return ErrInvalidStatus
+		}
+
+		openedPR, err = s.openPullRequest(ctx, pr, "pull request marked ready")
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Обновляем статус
-	pr.Status = models.StatusMerged
-	now := time.Now()
-	pr.MergedAt = &now
+	return openedPR, nil
+}
+
+// openPullRequest переводит PR в статус OPEN и назначает ревьюверов, если их нет.
+// Вызывается в транзакции, в которой строка PR уже заблокирована
+func (s *pullRequestService) openPullRequest(ctx context.Context, pr *models.PullRequest, reason string) (*models.PullRequest, error) {
+	var reviewers []string
+	var choices []models.ReviewerChoice
+	var excluded []models.RuleExclusion
+	if len(pr.AssignedReviewers) == 0 {
+		author, err := s.userRepo.Get(ctx, pr.AuthorID)
+		if err != nil {
+			return nil, ErrUserNotFound
+		}
+
+		changedFiles, err := s.prRepo.GetChangedFiles(ctx, pr.PullRequestID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get changed files: %w", err)
+		}
 
+		choices, excluded, err = s.initialReviewers(ctx, author, changedFiles, pr.Tags)
+		if err != nil {
+			return nil, err
+		}
+		reviewers = reviewerIDs(choices)
+	}
+
+	pr.Status = models.StatusOpen
+	pr.ClosedAt = nil
 	if err := s.prRepo.Update(ctx, pr); err != nil {
-		return nil, fmt.Errorf("failed to merge PR: %w", err)
+		return nil, fmt.Errorf("failed to open PR: %w", err)
 	}
 
+	for _, reviewerID := range reviewers {
+		if err := s.prRepo.AssignReviewer(ctx, pr.PullRequestID, reviewerID); err != nil {
+			return nil, fmt.Errorf("failed to assign reviewer: %w", err)
+		}
+	}
+	if err := s.recordAssigned(ctx, pr.PullRequestID, reviewers, reason); err != nil {
+		return nil, err
+	}
+	if err := s.emitAssigned(ctx, pr.PullRequestID, reviewers); err != nil {
+		return nil, err
+	}
+
+	updatedPR, err := s.prRepo.Get(ctx, pr.PullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get updated PR: %w", err)
+	}
+	updatedPR.Selection = choices
+	updatedPR.Excluded = excluded
+
+	return updatedPR, nil
+}
+
+// ReassignReviewer переназначает ревьювера, записывая причину в историю.
+// Вся операция выполняется в одной транзакции с блокировкой строки PR,
+// поэтому параллельные переназначения одного PR выполняются по очереди
+// и видят результат друг друга
+func (s *pullRequestService) ReassignReviewer(ctx context.Context, prID, oldReviewerID, reason string) (*models.PullRequest, string, error) {
+	var updatedPR *models.PullRequest
+	var newReviewerID string
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		var excluded []models.RuleExclusion
+		lookup := s.newLookup()
+		newReviewerID, excluded, err = s.reassign(ctx, lookup, prID, oldReviewerID, reason)
+		if err != nil {
+			return err
+		}
+
+		// Получаем обновленный PR
+		updatedPR, err = s.prRepo.Get(ctx, prID)
+		if err != nil {
+			return fmt.Errorf("failed to get updated PR: %w", err)
+		}
+		updatedPR.Excluded = excluded
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return updatedPR, newReviewerID, nil
+}
+
+// lockPullRequest читает PR, блокируя его строку до конца транзакции.
+// В ErrPRNotFound превращается только отсутствие PR, прочие ошибки БД
+// возвращаются как есть, чтобы не отвечать на сбой 404
+func (s *pullRequestService) lockPullRequest(ctx context.Context, prID string) (*models.PullRequest, error) {
+	pr, err := s.prRepo.GetForUpdate(ctx, prID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPRNotFound) {
+			return nil, ErrPRNotFound
+		}
+		return nil, fmt.Errorf("failed to lock PR: %w", err)
+	}
 	return pr, nil
 }
 
-// ReassignReviewer переназначает ревьювера
-func (s *pullRequestService) ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (*models.PullRequest, string, error) {
-	// Получаем PR
-	pr, err := s.prRepo.Get(ctx, prID)
+// reassign заменяет ревьювера PR внутри уже открытой транзакции и возвращает
+// ID нового ревьювера и кандидатов, исключенных правилами назначения
+func (s *pullRequestService) reassign(
+	ctx context.Context,
+	lookup *reviewerLookup,
+	prID, oldReviewerID, reason string,
+) (string, []models.RuleExclusion, error) {
+	// Блокируем PR до конца транзакции
+	pr, err := s.lockPullRequest(ctx, prID)
 	if err != nil {
-		return nil, "", ErrPRNotFound
+		return "", nil, err
 	}
 
-	// Проверяем, что PR не merged
+	// Переназначать можно только у открытого PR
 	if pr.Status == models.StatusMerged {
-		return nil, "", ErrPRMerged
+		return "", nil, ErrPRMerged
+	}
+	if pr.Status != models.StatusOpen {
+		return "", nil, ErrInvalidStatus
 	}
 
-	// Проверяем, что oldReviewerID назначен на этот PR
-	isAssigned, err := s.prRepo.IsReviewerAssigned(ctx, prID, oldReviewerID)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to check reviewer assignment: %w", err)
+	// Проверяем, что oldReviewerID назначен на этот PR. Ревьюверы уже
+	// прочитаны вместе с заблокированной строкой
+	isAssigned := false
+	for _, reviewerID := range pr.AssignedReviewers {
+		if reviewerID == oldReviewerID {
+			isAssigned = true
+			break
+		}
 	}
 	if !isAssigned {
-		return nil, "", ErrReviewerNotFound
+		return "", nil, ErrReviewerNotFound
 	}
 
 	// Получаем старого ревьювера для определения его команды
-	oldReviewer, err := s.userRepo.Get(ctx, oldReviewerID)
+	oldReviewer, err := lookup.user(ctx, oldReviewerID)
 	if err != nil {
-		return nil, "", ErrUserNotFound
+		return "", nil, err
 	}
 
-	// Получаем активных участников команды старого ревьювера
-	// Исключаем автора PR и текущих ревьюверов
-	candidates, err := s.userRepo.GetActiveTeammates(ctx, oldReviewer.TeamName, "")
+	author, err := lookup.user(ctx, pr.AuthorID)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get team candidates: %w", err)
+		return "", nil, err
 	}
 
-	// Фильтруем кандидатов: исключаем автора и уже назначенных ревьюверов
-	var filteredCandidates []*models.User
-	for _, candidate := range candidates {
-		if candidate.UserID == pr.AuthorID {
-			continue
-		}
-		alreadyAssigned := false
-		for _, reviewerID := range pr.AssignedReviewers {
-			if candidate.UserID == reviewerID {
-				alreadyAssigned = true
-				break
-			}
-		}
-		if !alreadyAssigned {
-			filteredCandidates = append(filteredCandidates, candidate)
+	// Сначала ищем замену среди владельцев измененных путей, затем в команде
+	// старого ревьювера и по цепочке резервных команд автора, как и при создании PR
+	changedFiles, err := s.prRepo.GetChangedFiles(ctx, prID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get changed files: %w", err)
+	}
+	owners, err := lookup.codeOwners(ctx, changedFiles)
+	if err != nil {
+		return "", nil, err
+	}
+	teams, err := lookup.reviewerTeams(ctx, oldReviewer.TeamName, author.TeamName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Исключаем автора и уже назначенных ревьюверов
+	exclude := map[string]bool{pr.AuthorID: true}
+	remaining := make([]string, 0, len(pr.AssignedReviewers))
+	for _, reviewerID := range pr.AssignedReviewers {
+		exclude[reviewerID] = true
+		if reviewerID != oldReviewerID {
+			remaining = append(remaining, reviewerID)
 		}
 	}
 
-	// Проверяем наличие кандидатов
-	if len(filteredCandidates) == 0 {
-		return nil, "", ErrNoCandidate
+	// Если старый ревьювер был единственным наставником оставшегося
+	// ревьювера, замена выбирается среди его наставников
+	policy, err := lookup.policy(ctx)
+	if err != nil {
+		return "", nil, err
 	}
+	only := policy.replacementMentors(remaining)
 
-	// Выбираем случайного кандидата
-	newReviewer := filteredCandidates[s.rand.Intn(len(filteredCandidates))]
+	minSeniority, err := s.replacementSeniority(ctx, lookup, pr, author, oldReviewer)
+	if err != nil {
+		return "", nil, err
+	}
+
+	selected, excluded, err := s.pickReviewers(ctx, reviewerRequest{
+		lookup:       lookup,
+		policyTeam:   author.TeamName,
+		owners:       owners,
+		tags:         pr.Tags,
+		minSeniority: minSeniority,
+		teams:        teams,
+		authorID:     pr.AuthorID,
+		assigned:     remaining,
+		only:         only,
+		exclude:      exclude,
+		count:        1,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if len(selected) == 0 {
+		if minSeniority != "" {
+			return "", nil, ErrNoSeniorCandidate
+		}
+		return "", nil, ErrNoCandidate
+	}
+	newReviewerID := selected[0].user.UserID
 
 	// Удаляем старого ревьювера
 	if err := s.prRepo.RemoveReviewer(ctx, prID, oldReviewerID); err != nil {
-		return nil, "", fmt.Errorf("failed to remove old reviewer: %w", err)
+		return "", nil, fmt.Errorf("failed to remove old reviewer: %w", err)
 	}
 
 	// Назначаем нового ревьювера
-	if err := s.prRepo.AssignReviewer(ctx, prID, newReviewer.UserID); err != nil {
-		return nil, "", fmt.Errorf("failed to assign new reviewer: %w", err)
+	if err := s.prRepo.AssignReviewer(ctx, prID, newReviewerID); err != nil {
+		return "", nil, fmt.Errorf("failed to assign new reviewer: %w", err)
+	}
+	lookup.changeLoad(oldReviewerID, -1)
+	lookup.changeLoad(newReviewerID, 1)
+
+	err = s.recordEvent(ctx, &models.AssignmentEvent{
+		PullRequestID:      prID,
+		EventType:          models.AssignmentEventReassigned,
+		ReviewerID:         newReviewerID,
+		PreviousReviewerID: oldReviewerID,
+		Reason:             reason,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = emit(ctx, s.events, s.clock, models.EventReviewerReassigned, map[string]interface{}{
+		"pull_request_id":      prID,
+		"reviewer_id":          newReviewerID,
+		"previous_reviewer_id": oldReviewerID,
+		"reason":               reason,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return newReviewerID, excluded, nil
+}
+
+// replacementSeniority возвращает уровень, которого должна достигать замена
+// oldReviewer, чтобы PR не перестал удовлетворять требованию команды автора.
+// Если старый ревьювер не нужен для выполнения требования, уровень не важен
+func (s *pullRequestService) replacementSeniority(
+	ctx context.Context,
+	lookup *reviewerLookup,
+	pr *models.PullRequest,
+	author, oldReviewer *models.User,
+) (models.Seniority, error) {
+	if author.TeamName == "" {
+		return "", nil
+	}
+	settings, err := lookup.teamSettings(ctx, author.TeamName)
+	if err != nil {
+		return "", err
+	}
+	if settings.RequiredSeniorityCount == 0 || !meetsSeniority(oldReviewer, settings.RequiredSeniority) {
+		return "", nil
+	}
+
+	remaining := 0
+	for _, reviewerID := range pr.AssignedReviewers {
+		if reviewerID == oldReviewer.UserID {
+			continue
+		}
+		reviewer, err := lookup.user(ctx, reviewerID)
+		if err != nil {
+			return "", err
+		}
+		if meetsSeniority(reviewer, settings.RequiredSeniority) {
+			remaining++
+		}
+	}
+	if remaining >= settings.RequiredSeniorityCount {
+		return "", nil
+	}
+	return settings.RequiredSeniority, nil
+}
+
+// ReassignOpenReviews переназначает все открытые ревью указанных ревьюверов
+// по правилам ReassignReviewer. Если замены нет, ревьювер остается назначенным,
+// а PR попадает в NoCandidate. Все изменения выполняются в одной транзакции
+func (s *pullRequestService) ReassignOpenReviews(ctx context.Context, reviewerIDs []string, reason string) (*models.ReassignmentReport, error) {
+	report := &models.ReassignmentReport{
+		Reassigned:  []models.ReviewReassignment{},
+		NoCandidate: []models.ReviewReassignment{},
+	}
+
+	if len(reviewerIDs) == 0 {
+		return report, nil
 	}
 
-	// Получаем обновленный PR
-	updatedPR, err := s.prRepo.Get(ctx, prID)
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Все назначения читаются одним запросом, а пользователи и составы
+		// команд - один раз на весь пакет
+		assignments, err := s.prRepo.GetOpenAssignments(ctx, reviewerIDs)
+		if err != nil {
+			return fmt.Errorf("failed to get reviewer assignments: %w", err)
+		}
+
+		lookup := s.newLookup()
+		for _, assignment := range assignments {
+			item := models.ReviewReassignment{
+				PullRequestID: assignment.PullRequestID,
+				OldReviewerID: assignment.ReviewerID,
+			}
+			newReviewerID, _, err := s.reassign(ctx, lookup, assignment.PullRequestID, assignment.ReviewerID, reason)
+			switch {
+			case errors.Is(err, ErrNoCandidate), errors.Is(err, ErrNoSeniorCandidate), errors.Is(err, ErrCapacityExhausted):
+				report.NoCandidate = append(report.NoCandidate, item)
+			case err != nil:
+				return err
+			default:
+				item.NewReviewerID = newReviewerID
+				report.Reassigned = append(report.Reassigned, item)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get updated PR: %w", err)
+		return nil, err
 	}
 
-	return updatedPR, newReviewer.UserID, nil
+	return report, nil
 }
 
-// selectRandomReviewers выбирает случайных ревьюверов из списка кандидатов
-func (s *pullRequestService) selectRandomReviewers(candidates []*models.User, maxCount int) []string {
-	count := len(candidates)
-	if count > maxCount {
-		count = maxCount
+// SetTags заменяет теги PR. Уже назначенные ревьюверы не меняются,
+// теги учитываются при следующих назначениях
+func (s *pullRequestService) SetTags(ctx context.Context, prID string, tags []string) (*models.PullRequest, error) {
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
 	}
 
-	if count == 0 {
-		return []string{}
+	var updatedPR *models.PullRequest
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.lockPullRequest(ctx, prID); err != nil {
+			return err
+		}
+		if err := s.prRepo.SetTags(ctx, prID, normalized); err != nil {
+			return err
+		}
+
+		updatedPR, err = s.prRepo.Get(ctx, prID)
+		if err != nil {
+			return fmt.Errorf("failed to get updated PR: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Создаем копию индексов
-	indices := make([]int, len(candidates))
-	for i := range indices {
-		indices[i] = i
+	return updatedPR, nil
+}
+
+// GetHistory возвращает историю назначений ревьюверов PR
+func (s *pullRequestService) GetHistory(ctx context.Context, prID string) ([]*models.AssignmentEvent, error) {
+	exists, err := s.prRepo.Exists(ctx, prID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check PR existence: %w", err)
+	}
+	if !exists {
+		return nil, ErrPRNotFound
 	}
 
-	// Перемешиваем индексы
-	s.rand.Shuffle(len(indices), func(i, j int) {
-		indices[i], indices[j] = indices[j], indices[i]
+	events, err := s.eventRepo.GetByPullRequest(ctx, prID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR history: %w", err)
+	}
+	return events, nil
+}
+
+// recordAssigned записывает в историю назначение каждого из ревьюверов
+func (s *pullRequestService) recordAssigned(ctx context.Context, prID string, reviewers []string, reason string) error {
+	for _, reviewerID := range reviewers {
+		err := s.recordEvent(ctx, &models.AssignmentEvent{
+			PullRequestID: prID,
+			EventType:     models.AssignmentEventAssigned,
+			ReviewerID:    reviewerID,
+			Reason:        reason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitAssigned записывает reviewer.assigned для каждого назначенного ревьювера
+func (s *pullRequestService) emitAssigned(ctx context.Context, prID string, reviewers []string) error {
+	for _, reviewerID := range reviewers {
+		err := emit(ctx, s.events, s.clock, models.EventReviewerAssigned, map[string]interface{}{
+			"pull_request_id": prID,
+			"reviewer_id":     reviewerID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordEvent записывает событие истории от имени пользователя из контекста
+func (s *pullRequestService) recordEvent(ctx context.Context, event *models.AssignmentEvent) error {
+	event.ActorID = actorFromContext(ctx)
+	if err := s.eventRepo.Add(ctx, event); err != nil {
+		return fmt.Errorf("failed to record assignment event: %w", err)
+	}
+	return nil
+}
+
+// SubmitReview сохраняет решение назначенного ревьювера по открытому PR.
+// Строка PR блокируется, чтобы решение не записалось в PR, который
+// параллельно сливается или закрывается
+func (s *pullRequestService) SubmitReview(ctx context.Context, prID, reviewerID string, decision models.ReviewDecision) (*models.Review, error) {
+	var review *models.Review
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		pr, err := s.lockPullRequest(ctx, prID)
+		if err != nil {
+			return err
+		}
+
+		if pr.Status == models.StatusMerged {
+			return ErrPRMerged
+		}
+		if pr.Status != models.StatusOpen {
+			return ErrInvalidStatus
+		}
+
+		isAssigned, err := s.prRepo.IsReviewerAssigned(ctx, prID, reviewerID)
+		if err != nil {
+			return fmt.Errorf("failed to check reviewer assignment: %w", err)
+		}
+		if !isAssigned {
+			return ErrReviewerNotFound
+		}
+
+		if err := s.prRepo.SetReviewDecision(ctx, prID, reviewerID, decision); err != nil {
+			return fmt.Errorf("failed to set review decision: %w", err)
+		}
+
+		reviews, err := s.prRepo.GetReviews(ctx, prID)
+		if err != nil {
+			return fmt.Errorf("failed to get reviews: %w", err)
+		}
+		for i := range reviews {
+			if reviews[i].ReviewerID == reviewerID {
+				review = &reviews[i]
+				return nil
+			}
+		}
+		return ErrReviewerNotFound
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// checkApprovals проверяет, что PR набрал required_approvals команды автора
+func (s *pullRequestService) checkApprovals(ctx context.Context, pr *models.PullRequest) error {
+	author, err := s.userRepo.Get(ctx, pr.AuthorID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	settings, err := s.teamRepo.GetSettings(ctx, author.TeamName)
+	if err != nil {
+		return fmt.Errorf("failed to get team settings: %w", err)
+	}
+	settings = applyPolicy(settings, s.policies.ForTeam(author.TeamName))
+	if settings.RequiredApprovals == 0 {
+		return nil
+	}
+
+	reviews, err := s.prRepo.GetReviews(ctx, pr.PullRequestID)
+	if err != nil {
+		return fmt.Errorf("failed to get reviews: %w", err)
+	}
+
+	approvals := 0
+	for _, review := range reviews {
+		if review.Decision == models.DecisionApproved {
+			approvals++
+		}
+	}
+	if approvals < settings.RequiredApprovals {
+		return ErrApprovalsRequired
+	}
+
+	return nil
+}
+
+// newLookup создает lookup на время одной операции
+func (s *pullRequestService) newLookup() *reviewerLookup {
+	return newReviewerLookup(s.userRepo, s.teamRepo, s.prRepo, s.ownershipRepo, s.ruleRepo, s.policies)
+}
+
+// initialReviewers выбирает ревьюверов для нового PR автора в пределах
+// min_reviewers..max_reviewers его команды и возвращает их вместе с
+// кандидатами, исключенными правилами назначения
+func (s *pullRequestService) initialReviewers(
+	ctx context.Context,
+	author *models.User,
+	changedFiles, tags []string,
+) ([]models.ReviewerChoice, []models.RuleExclusion, error) {
+	// Пользователь, исключенный из команды, не может открыть PR
+	if author.TeamName == "" {
+		return nil, nil, ErrTeamNotFound
+	}
+
+	lookup := s.newLookup()
+	settings, err := lookup.teamSettings(ctx, author.TeamName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Кандидаты берутся из владельцев измененных путей, затем из команды
+	// автора и ее резервных команд
+	owners, err := lookup.codeOwners(ctx, changedFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	teams, err := lookup.reviewerTeams(ctx, author.TeamName, author.TeamName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req := reviewerRequest{
+		lookup:     lookup,
+		policyTeam: author.TeamName,
+		owners:     owners,
+		tags:       tags,
+		teams:      teams,
+		authorID:   author.UserID,
+		exclude:    map[string]bool{author.UserID: true},
+	}
+
+	// Сначала выбираем ревьюверов требуемого командой уровня, затем добираем
+	// остальных до max_reviewers, исключая автора и уже выбранных
+	var selected []pickedReviewer
+	var excluded []models.RuleExclusion
+	if settings.RequiredSeniorityCount > 0 {
+		seniorReq := req
+		seniorReq.minSeniority = settings.RequiredSeniority
+		seniorReq.count = settings.RequiredSeniorityCount
+		selected, excluded, err = s.pickReviewers(ctx, seniorReq)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(selected) < settings.RequiredSeniorityCount {
+			return nil, nil, ErrNoSeniorCandidate
+		}
+
+		req.exclude = map[string]bool{author.UserID: true}
+		for _, reviewer := range selected {
+			req.exclude[reviewer.user.UserID] = true
+			req.assigned = append(req.assigned, reviewer.user.UserID)
+		}
+	}
+
+	// Требование к уровню из политики может превышать max_reviewers,
+	// тогда остальные ревьюверы не добираются
+	req.count = settings.MaxReviewers - len(selected)
+	if req.count < 0 {
+		req.count = 0
+	}
+	rest, restExcluded, err := s.pickReviewers(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	selected = append(selected, rest...)
+	excluded = remainingExclusions(append(excluded, restExcluded...), selected)
+	if len(selected) < settings.MinReviewers {
+		return nil, nil, ErrNotEnoughReviewers
+	}
+
+	choices := make([]models.ReviewerChoice, 0, len(selected))
+	for _, reviewer := range selected {
+		choices = append(choices, models.ReviewerChoice{
+			ReviewerID: reviewer.user.UserID,
+			Reasons:    reviewer.reasons,
+		})
+	}
+	return choices, excluded, nil
+}
+
+// reviewerIDs возвращает ID выбранных ревьюверов
+func reviewerIDs(choices []models.ReviewerChoice) []string {
+	ids := make([]string, 0, len(choices))
+	for _, choice := range choices {
+		ids = append(ids, choice.ReviewerID)
+	}
+	return ids
+}
+
+// reviewerRequest описывает параметры выбора ревьюверов
+type reviewerRequest struct {
+	// lookup - источник кандидатов и резервных команд
+	lookup *reviewerLookup
+	// policyTeam - команда автора PR, чьи настройки применяются
+	policyTeam string
+	// owners - владельцы измененных путей, выбираются в первую очередь
+	owners []*models.User
+	// tags - теги PR, по которым ранжируются навыки кандидатов
+	tags []string
+	// minSeniority - если задан, выбираются только кандидаты этого уровня или выше
+	minSeniority models.Seniority
+	// authorID - автор PR, для правил EXCLUDE
+	authorID string
+	// assigned - ревьюверы, которые остаются на PR, для правил PAIR
+	assigned []string
+	// only - если не nil, выбираются только эти пользователи
+	only map[string]bool
+	// teams - команды, из которых по порядку берутся кандидаты
+	teams []string
+	// exclude - пользователи, которых нельзя назначать
+	exclude map[string]bool
+	count   int
+}
+
+// pickedReviewer - выбранный ревьювер и причины выбора
+type pickedReviewer struct {
+	user    *models.User
+	reasons []string
+}
+
+// remainingExclusions оставляет по одному исключению на пользователя,
+// отбрасывая тех, кто в итоге все же выбран
+func remainingExclusions(excluded []models.RuleExclusion, selected []pickedReviewer) []models.RuleExclusion {
+	seen := make(map[string]bool, len(selected)+len(excluded))
+	for _, reviewer := range selected {
+		seen[reviewer.user.UserID] = true
+	}
+
+	var result []models.RuleExclusion
+	for _, exclusion := range excluded {
+		if !seen[exclusion.UserID] {
+			seen[exclusion.UserID] = true
+			result = append(result, exclusion)
+		}
+	}
+	return result
+}
+
+// pickReviewers выбирает до count ревьюверов: сначала среди владельцев
+// измененных путей, затем проходя команды по порядку, пока не наберется
+// нужное число. В каждой команде применяется ее стратегия, к владельцам -
+// стратегия команды автора. Кандидаты, достигшие лимита открытых ревью,
+// пропускаются. Внутри пула сначала выбираются кандидаты с большим числом
+// навыков, совпавших с тегами PR, а если команда автора предпочитает рабочие
+// часы - те, у кого сейчас рабочее время. Кандидаты, нарушающие правила
+// назначения, пропускаются и возвращаются вторым значением
+func (s *pullRequestService) pickReviewers(ctx context.Context, req reviewerRequest) ([]pickedReviewer, []models.RuleExclusion, error) {
+	preferWorkingHours := false
+	if req.policyTeam != "" {
+		settings, err := req.lookup.teamSettings(ctx, req.policyTeam)
+		if err != nil {
+			return nil, nil, err
+		}
+		preferWorkingHours = settings.PreferWorkingHours
+	}
+	now := s.clock.Now()
+	// Стратегии выбора читают загрузку ревьюверов через кэш lookup
+	ctx = withLookup(ctx, req.lookup)
+
+	policy, err := req.lookup.policy(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exclude := make(map[string]bool, len(req.exclude))
+	for userID := range req.exclude {
+		exclude[userID] = true
+	}
+
+	// reviewers - ревьюверы PR вместе с уже выбранными, для правил PAIR
+	reviewers := make(map[string]bool, len(req.assigned)+req.count)
+	for _, userID := range req.assigned {
+		reviewers[userID] = true
+	}
+
+	selected := make([]pickedReviewer, 0, req.count)
+	var excluded []models.RuleExclusion
+	capacityLimited := false
+
+	// pick выбирает недостающих ревьюверов из пула по стратегии команды team
+	pick := func(team string, pool []*models.User, reason string) error {
+		candidates := make([]*models.User, 0, len(pool))
+		for _, candidate := range pool {
+			if exclude[candidate.UserID] {
+				continue
+			}
+			if req.minSeniority != "" && !meetsSeniority(candidate, req.minSeniority) {
+				continue
+			}
+			if req.only != nil && !req.only[candidate.UserID] {
+				continue
+			}
+			if exclusion := policy.check(candidate.UserID, req.authorID, req.policyTeam, reviewers); exclusion != nil {
+				excluded = append(excluded, *exclusion)
+				continue
+			}
+			candidates = append(candidates, candidate)
+		}
 
-	// Выбираем первые count элементов
-	reviewers := make([]string, count)
-	for i := 0; i < count; i++ {
-		reviewers[i] = candidates[indices[i]].UserID
+		available, err := s.filterByCapacity(ctx, req.lookup, candidates)
+		if err != nil {
+			return err
+		}
+		if len(available) < len(candidates) {
+			capacityLimited = true
+		}
+
+		var groups [][]*models.User
+		for _, group := range groupBySkills(available, req.tags) {
+			if preferWorkingHours {
+				working, other := preferWorking(group, now)
+				groups = append(groups, working, other)
+			} else {
+				groups = append(groups, group)
+			}
+		}
+
+		for _, group := range groups {
+			if len(group) == 0 || len(selected) >= req.count {
+				continue
+			}
+			picked, err := s.selectors.ForTeam(team).Select(ctx, team, group, req.count-len(selected))
+			if err != nil {
+				return fmt.Errorf("failed to select reviewers: %w", err)
+			}
+			for _, reviewer := range picked {
+				reasons := []string{reason}
+				if req.minSeniority != "" {
+					reasons = append(reasons, "required seniority "+string(req.minSeniority))
+				}
+				if matched := matchedSkills(reviewer, req.tags); len(matched) > 0 {
+					reasons = append(reasons, "matched skills: "+strings.Join(matched, ", "))
+				}
+				if preferWorkingHours && inWorkingHours(reviewer, now) {
+					reasons = append(reasons, "within working hours")
+				}
+
+				exclude[reviewer.UserID] = true
+				reviewers[reviewer.UserID] = true
+				selected = append(selected, pickedReviewer{user: reviewer, reasons: reasons})
+			}
+		}
+		return nil
+	}
+
+	if len(req.owners) > 0 {
+		if err := pick(req.policyTeam, req.owners, "code owner of changed files"); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	return reviewers
+	for i, team := range req.teams {
+		if len(selected) >= req.count {
+			break
+		}
+
+		teammates, err := req.lookup.activeTeammates(ctx, team)
+		if err != nil {
+			return nil, nil, err
+		}
+		reason := "member of team " + team
+		if i > 0 {
+			reason = "member of fallback team " + team
+		}
+		if err := pick(team, teammates, reason); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// В строгом режиме нехватка свободных кандидатов из-за лимитов - ошибка
+	if len(selected) < req.count && capacityLimited && s.selectors.StrictCapacity(req.policyTeam) {
+		return nil, nil, ErrCapacityExhausted
+	}
+
+	return selected, remainingExclusions(excluded, selected), nil
+}
+
+// filterByCapacity исключает кандидатов, у которых открытых ревью не меньше max_open_reviews
+func (s *pullRequestService) filterByCapacity(ctx context.Context, lookup *reviewerLookup, candidates []*models.User) ([]*models.User, error) {
+	var limited []*models.User
+	for _, candidate := range candidates {
+		if candidate.MaxOpenReviews != nil {
+			limited = append(limited, candidate)
+		}
+	}
+	if len(limited) == 0 {
+		return candidates, nil
+	}
+
+	load, err := lookup.openLoad(ctx, limited)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]*models.User, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.MaxOpenReviews != nil && load[candidate.UserID] >= *candidate.MaxOpenReviews {
+			continue
+		}
+		available = append(available, candidate)
+	}
+	return available, nil
 }
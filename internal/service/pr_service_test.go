@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/database/dbtest"
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// newTestPullRequestService собирает PullRequestService на репозиториях
+// тестовой БД со случайным выбором ревьюверов и без политик
+func newTestPullRequestService(t *testing.T, db *sql.DB) PullRequestService {
+	t.Helper()
+
+	prRepo := repository.NewPullRequestRepository(db)
+	selectors, err := NewSelectorRegistry(SelectorConfig{DefaultStrategy: StrategyRandom}, prRepo)
+	if err != nil {
+		t.Fatalf("failed to create selectors: %v", err)
+	}
+	return NewPullRequestService(
+		repository.NewUserRepository(db),
+		prRepo,
+		repository.NewTeamRepository(db),
+		repository.NewAssignmentEventRepository(db),
+		repository.NewOwnershipRepository(db),
+		repository.NewRuleRepository(db),
+		repository.NewTransactor(db),
+		selectors,
+		nil,
+		NewOutboxPublisher(repository.NewOutboxRepository(db)),
+		SystemClock,
+	)
+}
+
+// createTestTeam создает команду с активными участниками userIDs
+func createTestTeam(t *testing.T, db *sql.DB, teamName string, userIDs ...string) {
+	t.Helper()
+
+	team := &models.Team{TeamName: teamName}
+	for _, userID := range userIDs {
+		team.Members = append(team.Members, models.TeamMember{UserID: userID, Username: userID, IsActive: true})
+	}
+	if err := repository.NewTeamRepository(db).CreateWithMembers(context.Background(), team); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+}
+
+func TestCreatePullRequestConcurrent(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	prService := newTestPullRequestService(t, db)
+	createTestTeam(t, db, "race-team", "race-author", "race-reviewer-1", "race-reviewer-2")
+
+	const attempts = 16
+	start := make(chan struct{})
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := prService.CreatePullRequest(ctx, CreatePullRequestParams{
+				PullRequestID:   "race-pr",
+				PullRequestName: "Race",
+				AuthorID:        "race-author",
+			})
+			errs <- err
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrPRExists):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("created %d times, want exactly once", created)
+	}
+}
+
+// Параллельные слияние и закрытие одного PR выполняются по очереди:
+// успешен только первый переход, второй видит новый статус
+func TestMergeAndCloseConcurrent(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	prService := newTestPullRequestService(t, db)
+	createTestTeam(t, db, "race-team", "race-author", "race-reviewer")
+
+	for i := 0; i < 10; i++ {
+		prID := fmt.Sprintf("race-pr-%d", i)
+		_, err := prService.CreatePullRequest(ctx, CreatePullRequestParams{
+			PullRequestID:   prID,
+			PullRequestName: "Race",
+			AuthorID:        "race-author",
+		})
+		if err != nil {
+			t.Fatalf("failed to create PR: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		var mergeErr, closeErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, mergeErr = prService.MergePullRequest(ctx, prID, true)
+		}()
+		go func() {
+			defer wg.Done()
+			_, closeErr = prService.ClosePullRequest(ctx, prID)
+		}()
+		wg.Wait()
+
+		switch {
+		case mergeErr == nil && errors.Is(closeErr, ErrPRMerged):
+		case closeErr == nil && errors.Is(mergeErr, ErrInvalidStatus):
+		default:
+			t.Fatalf("merge error = %v, close error = %v, want exactly one transition", mergeErr, closeErr)
+		}
+
+		history, err := prService.GetHistory(ctx, prID)
+		if err != nil {
+			t.Fatalf("failed to get history: %v", err)
+		}
+		transitions := 0
+		for _, event := range history {
+			if event.EventType == models.AssignmentEventMerged || event.EventType == models.AssignmentEventClosed {
+				transitions++
+			}
+		}
+		if transitions != 1 {
+			t.Errorf("history has %d transitions, want 1", transitions)
+		}
+	}
+}
+
+func TestInitialReviewersFallbackChain(t *testing.T) {
+	author := member("author", "backend")
+	inactive := member("idle", "frontend")
+	inactive.IsActive = false
+	users := &fakeUserRepository{users: []*models.User{
+		author,
+		member("alice", "backend"),
+		inactive,
+		member("carol", "mobile"),
+		member("dave", "mobile"),
+		member("erin", "platform"),
+	}}
+
+	tests := []struct {
+		name      string
+		settings  models.TeamSettings
+		fallbacks []string
+		policy    *models.TeamPolicy
+		want      []string
+		// wantReasons - причина выбора для каждого ревьювера
+		wantReasons map[string]string
+		wantErr     error
+	}{
+		{
+			name:      "own team is enough",
+			settings:  models.TeamSettings{MaxReviewers: 1},
+			fallbacks: []string{"mobile"},
+			want:      []string{"alice"},
+		},
+		{
+			// В frontend нет активных участников, недостающие берутся из mobile
+			name:      "skips team without candidates",
+			settings:  models.TeamSettings{MaxReviewers: 3},
+			fallbacks: []string{"frontend", "mobile", "platform"},
+			want:      []string{"alice", "carol", "dave"},
+			wantReasons: map[string]string{
+				"alice": "member of team backend",
+				"carol": "member of fallback team mobile",
+				"dave":  "member of fallback team mobile",
+			},
+		},
+		{
+			name:      "walks the whole chain",
+			settings:  models.TeamSettings{MaxReviewers: 5},
+			fallbacks: []string{"mobile", "platform"},
+			want:      []string{"alice", "carol", "dave", "erin"},
+		},
+		{
+			name:      "chain does not repeat own team",
+			settings:  models.TeamSettings{MaxReviewers: 5},
+			fallbacks: []string{"backend", "platform"},
+			want:      []string{"alice", "erin"},
+		},
+		{
+			name:      "policy replaces stored fallbacks",
+			settings:  models.TeamSettings{MaxReviewers: 2},
+			fallbacks: []string{"mobile"},
+			policy:    &models.TeamPolicy{Team: "backend", FallbackTeams: []string{"platform"}},
+			want:      []string{"alice", "erin"},
+		},
+		{
+			name:      "not enough for min reviewers",
+			settings:  models.TeamSettings{MinReviewers: 3, MaxReviewers: 3},
+			fallbacks: []string{"platform"},
+			wantErr:   ErrNotEnoughReviewers,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teams := &fakeTeamRepository{defaults: tt.settings, fallbacks: map[string][]string{"backend": tt.fallbacks}}
+			s := newReviewerTestService(users, teams, nil)
+			if tt.policy != nil {
+				policies, err := NewPolicySet([]*models.TeamPolicy{tt.policy})
+				if err != nil {
+					t.Fatalf("failed to create policies: %v", err)
+				}
+				s.policies = policies
+			}
+
+			choices, _, err := s.initialReviewers(context.Background(), author, nil, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("initialReviewers() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := choiceIDs(choices); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("picked %v, want %v", got, tt.want)
+			}
+			for _, choice := range choices {
+				if want, ok := tt.wantReasons[choice.ReviewerID]; ok && choice.Reasons[0] != want {
+					t.Errorf("reasons of %s = %q, want %q first", choice.ReviewerID, choice.Reasons, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAssignmentHistory(t *testing.T) {
+	users := &fakeUserRepository{users: []*models.User{
+		member("author", "backend"),
+		member("alice", "backend"),
+		member("bob", "backend"),
+		member("carol", "backend"),
+	}}
+	teams := &fakeTeamRepository{defaults: models.TeamSettings{MaxReviewers: 2}}
+	prRepo := newFakePullRequestRepository()
+	prService := newAssignmentTestService(t, users, teams, prRepo)
+	ctx := WithActor(context.Background(), "lead")
+
+	pr, err := prService.CreatePullRequest(ctx, CreatePullRequestParams{
+		PullRequestID:   "pr-1",
+		PullRequestName: "Add history",
+		AuthorID:        "author",
+	})
+	if err != nil {
+		t.Fatalf("CreatePullRequest() error = %v", err)
+	}
+	if len(pr.AssignedReviewers) != 2 {
+		t.Fatalf("assigned %v, want 2 reviewers", pr.AssignedReviewers)
+	}
+	oldReviewerID := pr.AssignedReviewers[0]
+
+	_, newReviewerID, err := prService.ReassignReviewer(ctx, "pr-1", oldReviewerID, "on vacation")
+	if err != nil {
+		t.Fatalf("ReassignReviewer() error = %v", err)
+	}
+	if _, err := prService.MergePullRequest(ctx, "pr-1", true); err != nil {
+		t.Fatalf("MergePullRequest() error = %v", err)
+	}
+
+	history, err := prService.GetHistory(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("GetHistory() error = %v", err)
+	}
+	want := []models.AssignmentEvent{
+		{ID: 1, PullRequestID: "pr-1", EventType: models.AssignmentEventAssigned, ReviewerID: pr.AssignedReviewers[0], ActorID: "lead", Reason: "pull request created"},
+		{ID: 2, PullRequestID: "pr-1", EventType: models.AssignmentEventAssigned, ReviewerID: pr.AssignedReviewers[1], ActorID: "lead", Reason: "pull request created"},
+		{ID: 3, PullRequestID: "pr-1", EventType: models.AssignmentEventReassigned, ReviewerID: newReviewerID, PreviousReviewerID: oldReviewerID, ActorID: "lead", Reason: "on vacation"},
+		{ID: 4, PullRequestID: "pr-1", EventType: models.AssignmentEventMerged, ActorID: "lead", Reason: "forced merge"},
+	}
+	got := make([]models.AssignmentEvent, 0, len(history))
+	for _, event := range history {
+		got = append(got, *event)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("history = %+v, want %+v", got, want)
+	}
+
+	if _, err := prService.GetHistory(ctx, "missing"); !errors.Is(err, ErrPRNotFound) {
+		t.Errorf("GetHistory(missing) error = %v, want %v", err, ErrPRNotFound)
+	}
+}
+
+func TestReassignReviewerLocksPullRequest(t *testing.T) {
+	merged := openPR("merged", "author", "alice")
+	merged.Status = models.StatusMerged
+
+	tests := []struct {
+		name          string
+		pr            *models.PullRequest
+		oldReviewerID string
+		wantErr       error
+		wantReviewers []string
+	}{
+		{name: "replaces reviewer", pr: openPR("pr-1", "author", "alice"), oldReviewerID: "alice", wantReviewers: []string{"bob"}},
+		{name: "merged PR", pr: merged, oldReviewerID: "alice", wantErr: ErrPRMerged, wantReviewers: []string{"alice"}},
+		{name: "reviewer not assigned", pr: openPR("pr-1", "author", "alice"), oldReviewerID: "bob", wantErr: ErrReviewerNotFound, wantReviewers: []string{"alice"}},
+		{name: "no candidate", pr: openPR("pr-1", "author", "alice", "bob"), oldReviewerID: "alice", wantErr: ErrNoCandidate, wantReviewers: []string{"alice", "bob"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUserRepository{users: []*models.User{
+				member("author", "backend"),
+				member("alice", "backend"),
+				member("bob", "backend"),
+			}}
+			teams := &fakeTeamRepository{defaults: models.TeamSettings{MaxReviewers: 2}}
+			prRepo := newFakePullRequestRepository(tt.pr)
+			prService := newAssignmentTestService(t, users, teams, prRepo)
+
+			_, newReviewerID, err := prService.ReassignReviewer(context.Background(), tt.pr.PullRequestID, tt.oldReviewerID, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReassignReviewer() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && newReviewerID != tt.wantReviewers[0] {
+				t.Errorf("new reviewer = %q, want %q", newReviewerID, tt.wantReviewers[0])
+			}
+
+			// Строка PR блокируется до проверки статуса и назначений
+			if want := []string{tt.pr.PullRequestID}; !reflect.DeepEqual(prRepo.locked, want) {
+				t.Errorf("locked %v, want %v", prRepo.locked, want)
+			}
+			if got := prRepo.prs[tt.pr.PullRequestID].AssignedReviewers; !reflect.DeepEqual(got, tt.wantReviewers) {
+				t.Errorf("reviewers = %v, want %v", got, tt.wantReviewers)
+			}
+		})
+	}
+}
+
+// fakeLockRepository возвращает заданную ошибку при блокировке PR
+type fakeLockRepository struct {
+	repository.PullRequestRepository
+	err error
+}
+
+func (r *fakeLockRepository) GetForUpdate(context.Context, string) (*models.PullRequest, error) {
+	return nil, r.err
+}
+
+func TestLockedOperationErrors(t *testing.T) {
+	operations := map[string]func(s PullRequestService) error{
+		"merge": func(s PullRequestService) error {
+			_, err := s.MergePullRequest(context.Background(), "pr-1", false)
+			return err
+		},
+		"close": func(s PullRequestService) error {
+			_, err := s.ClosePullRequest(context.Background(), "pr-1")
+			return err
+		},
+		"reopen": func(s PullRequestService) error {
+			_, err := s.ReopenPullRequest(context.Background(), "pr-1")
+			return err
+		},
+		"mark ready": func(s PullRequestService) error {
+			_, err := s.MarkReady(context.Background(), "pr-1")
+			return err
+		},
+		"reassign": func(s PullRequestService) error {
+			_, _, err := s.ReassignReviewer(context.Background(), "pr-1", "alice", "")
+			return err
+		},
+		"set tags": func(s PullRequestService) error {
+			_, err := s.SetTags(context.Background(), "pr-1", nil)
+			return err
+		},
+		"submit review": func(s PullRequestService) error {
+			_, err := s.SubmitReview(context.Background(), "pr-1", "alice", models.DecisionApproved)
+			return err
+		},
+	}
+
+	for name, operation := range operations {
+		t.Run(name, func(t *testing.T) {
+			missing := &pullRequestService{prRepo: &fakeLockRepository{err: repository.ErrPRNotFound}, tx: passthroughTransactor{}}
+			if err := operation(missing); !errors.Is(err, ErrPRNotFound) {
+				t.Errorf("missing PR: error = %v, want %v", err, ErrPRNotFound)
+			}
+
+			// Сбой БД не выдается за отсутствие PR
+			dbErr := errors.New("lock timeout")
+			failing := &pullRequestService{prRepo: &fakeLockRepository{err: dbErr}, tx: passthroughTransactor{}}
+			if err := operation(failing); !errors.Is(err, dbErr) || errors.Is(err, ErrPRNotFound) {
+				t.Errorf("database failure: error = %v, want wrapped %v", err, dbErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// reviewerLookup читает пользователей, активных участников, резервные команды
+// и загрузку ревьюверов, запоминая результат на время одной операции. Пакетное
+// переназначение использует один lookup для всех PR, чтобы не перечитывать
+// одни и те же данные
+type reviewerLookup struct {
+	userRepo      repository.UserRepository
+	teamRepo      repository.TeamRepository
+	prRepo        repository.PullRequestRepository
+	ownershipRepo repository.OwnershipRepository
+	ruleRepo      repository.RuleRepository
+	policies      *PolicySet
+	// rules - правила владения кодом, nil до первого обращения
+	rules []*models.OwnershipRule
+	// evaluator - правила назначения ревьюверов, nil до первого обращения
+	evaluator *policyEvaluator
+	users     map[string]*models.User
+	fallbacks map[string][]string
+	teammates map[string][]*models.User
+	settings  map[string]*models.TeamSettings
+	// load - число открытых ревью пользователей с учетом изменений операции
+	load map[string]int
+}
+
+// lookupKey - ключ контекста для lookup текущей операции
+type lookupKey struct{}
+
+// withLookup возвращает контекст, из которого стратегии выбора берут
+// загрузку ревьюверов через кэш lookup
+func withLookup(ctx context.Context, lookup *reviewerLookup) context.Context {
+	return context.WithValue(ctx, lookupKey{}, lookup)
+}
+
+// newReviewerLookup создает пустой lookup
+func newReviewerLookup(
+	userRepo repository.UserRepository,
+	teamRepo repository.TeamRepository,
+	prRepo repository.PullRequestRepository,
+	ownershipRepo repository.OwnershipRepository,
+	ruleRepo repository.RuleRepository,
+	policies *PolicySet,
+) *reviewerLookup {
+	return &reviewerLookup{
+		userRepo:      userRepo,
+		teamRepo:      teamRepo,
+		prRepo:        prRepo,
+		ownershipRepo: ownershipRepo,
+		ruleRepo:      ruleRepo,
+		policies:      policies,
+		users:         make(map[string]*models.User),
+		fallbacks:     make(map[string][]string),
+		teammates:     make(map[string][]*models.User),
+		settings:      make(map[string]*models.TeamSettings),
+		load:          make(map[string]int),
+	}
+}
+
+// user возвращает пользователя по ID
+func (l *reviewerLookup) user(ctx context.Context, userID string) (*models.User, error) {
+	if user, ok := l.users[userID]; ok {
+		return user, nil
+	}
+
+	user, err := l.userRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	l.users[userID] = user
+	return user, nil
+}
+
+// teamSettings возвращает настройки команды с учетом ее политики
+func (l *reviewerLookup) teamSettings(ctx context.Context, team string) (*models.TeamSettings, error) {
+	if settings, ok := l.settings[team]; ok {
+		return settings, nil
+	}
+
+	settings, err := l.teamRepo.GetSettings(ctx, team)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team settings: %w", err)
+	}
+	settings = applyPolicy(settings, l.policies.ForTeam(team))
+	l.settings[team] = settings
+	return settings, nil
+}
+
+// policy возвращает evaluator правил назначения ревьюверов
+func (l *reviewerLookup) policy(ctx context.Context) (*policyEvaluator, error) {
+	if l.evaluator != nil {
+		return l.evaluator, nil
+	}
+
+	rules, err := l.ruleRepo.ListRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviewer rules: %w", err)
+	}
+	l.evaluator = newPolicyEvaluator(rules, l.policies)
+	return l.evaluator, nil
+}
+
+// activeTeammates возвращает активных участников команды
+func (l *reviewerLookup) activeTeammates(ctx context.Context, team string) ([]*models.User, error) {
+	if teammates, ok := l.teammates[team]; ok {
+		return teammates, nil
+	}
+
+	teammates, err := l.userRepo.GetActiveTeammates(ctx, team, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team candidates: %w", err)
+	}
+	l.teammates[team] = teammates
+	return teammates, nil
+}
+
+// openLoad возвращает число открытых ревью кандидатов. Из БД читаются только
+// кандидаты, которых еще нет в кэше
+func (l *reviewerLookup) openLoad(ctx context.Context, candidates []*models.User) (map[string]int, error) {
+	var missing []*models.User
+	for _, candidate := range candidates {
+		if _, ok := l.load[candidate.UserID]; !ok {
+			missing = append(missing, candidate)
+		}
+	}
+
+	if len(missing) > 0 {
+		counts, err := openReviewLoad(ctx, l.prRepo, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, candidate := range missing {
+			l.load[candidate.UserID] = counts[candidate.UserID]
+		}
+	}
+	return l.load, nil
+}
+
+// changeLoad учитывает назначение (delta > 0) или снятие (delta < 0)
+// ревьювера, сделанное операцией, в кэше загрузки
+func (l *reviewerLookup) changeLoad(userID string, delta int) {
+	if _, ok := l.load[userID]; ok {
+		l.load[userID] += delta
+	}
+}
+
+// reviewerTeams возвращает основную команду и резервные команды chainOwner
+// по порядку. Резервные команды из политики chainOwner заменяют сохраненные
+func (l *reviewerLookup) reviewerTeams(ctx context.Context, primary, chainOwner string) ([]string, error) {
+	fallbacks, ok := l.fallbacks[chainOwner]
+	if policy := l.policies.ForTeam(chainOwner); !ok && policy != nil && policy.FallbackTeams != nil {
+		fallbacks, ok = policy.FallbackTeams, true
+		l.fallbacks[chainOwner] = fallbacks
+	}
+	if !ok {
+		var err error
+		fallbacks, err = l.teamRepo.GetFallbackTeams(ctx, chainOwner)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get fallback teams: %w", err)
+		}
+		l.fallbacks[chainOwner] = fallbacks
+	}
+
+	teams := []string{primary}
+	for _, team := range fallbacks {
+		if team != primary {
+			teams = append(teams, team)
+		}
+	}
+	return teams, nil
+}
+
+// codeOwners возвращает доступных владельцев путей: пользователей из правил
+// и активных участников команд из правил
+func (l *reviewerLookup) codeOwners(ctx context.Context, paths []string) ([]*models.User, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	if l.rules == nil {
+		rules, err := l.ownershipRepo.ListRules(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ownership rules: %w", err)
+		}
+		l.rules = rules
+	}
+
+	userIDs, teams := ownersForPaths(l.rules, paths)
+
+	var owners []*models.User
+	seen := make(map[string]bool)
+	if len(userIDs) > 0 {
+		users, err := l.userRepo.GetAvailableByIDs(ctx, userIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get code owners: %w", err)
+		}
+		for _, user := range users {
+			seen[user.UserID] = true
+			owners = append(owners, user)
+		}
+	}
+	for _, team := range teams {
+		teammates, err := l.activeTeammates(ctx, team)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range teammates {
+			if !seen[user.UserID] {
+				seen[user.UserID] = true
+				owners = append(owners, user)
+			}
+		}
+	}
+	return owners, nil
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// Названия встроенных стратегий выбора ревьюверов
+const (
+	StrategyRandom      = "random"
+	StrategyRoundRobin  = "round_robin"
+	StrategyLeastLoaded = "least_loaded"
+	StrategyWeighted    = "weighted"
+)
+
+// knownStrategy проверяет, что стратегия с таким названием есть
+func knownStrategy(name string) bool {
+	switch name {
+	case StrategyRandom, StrategyRoundRobin, StrategyLeastLoaded, StrategyWeighted:
+		return true
+	}
+	return false
+}
+
+// ReviewerSelector выбирает до count ревьюверов из кандидатов команды team
+type ReviewerSelector interface {
+	Select(ctx context.Context, team string, candidates []*models.User, count int) ([]*models.User, error)
+}
+
+// SelectorConfig описывает выбор стратегий для развертывания и отдельных команд
+type SelectorConfig struct {
+	DefaultStrategy     string
+	TeamStrategies      map[string]string
+	Weights             map[string]int
+	StrictCapacityTeams []string
+}
+
+// SelectorRegistry хранит стратегии и настройки выбора для команд
+type SelectorRegistry struct {
+	defaultSelector ReviewerSelector
+	teamSelectors   map[string]ReviewerSelector
+	strictCapacity  map[string]bool
+	rotation        *roundRobinSelector
+}
+
+// NewSelectorRegistry создает реестр стратегий выбора ревьюверов
+func NewSelectorRegistry(cfg SelectorConfig, prRepo repository.PullRequestRepository) (*SelectorRegistry, error) {
+	rnd := newLockedRand(time.Now().UnixNano())
+	rotation := &roundRobinSelector{cursors: make(map[string]rotationCursor)}
+	builtins := map[string]ReviewerSelector{
+		StrategyRandom:      &randomSelector{rand: rnd},
+		StrategyRoundRobin:  rotation,
+		StrategyLeastLoaded: &leastLoadedSelector{rand: rnd, prRepo: prRepo},
+		StrategyWeighted:    &weightedSelector{rand: rnd, weights: cfg.Weights},
+	}
+
+	defaultName := cfg.DefaultStrategy
+	if defaultName == "" {
+		defaultName = StrategyRandom
+	}
+	defaultSelector, ok := builtins[defaultName]
+	if !ok {
+		return nil, fmt.Errorf("unknown reviewer strategy %q", defaultName)
+	}
+
+	teamSelectors := make(map[string]ReviewerSelector, len(cfg.TeamStrategies))
+	for team, name := range cfg.TeamStrategies {
+		selector, ok := builtins[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown reviewer strategy %q for team %s", name, team)
+		}
+		teamSelectors[team] = selector
+	}
+
+	strictCapacity := make(map[string]bool, len(cfg.StrictCapacityTeams))
+	for _, team := range cfg.StrictCapacityTeams {
+		strictCapacity[team] = true
+	}
+
+	return &SelectorRegistry{
+		defaultSelector: defaultSelector,
+		teamSelectors:   teamSelectors,
+		strictCapacity:  strictCapacity,
+		rotation:        rotation,
+	}, nil
+}
+
+// ForTeam возвращает стратегию команды или стратегию по умолчанию
+func (r *SelectorRegistry) ForTeam(teamName string) ReviewerSelector {
+	if selector, ok := r.teamSelectors[teamName]; ok {
+		return selector
+	}
+	return r.defaultSelector
+}
+
+// StrictCapacity сообщает, должна ли команда получать CAPACITY_EXHAUSTED
+// вместо назначения меньшего числа ревьюверов
+func (r *SelectorRegistry) StrictCapacity(teamName string) bool {
+	return r.strictCapacity[teamName]
+}
+
+// Configured сообщает, что для команды в конфигурации задана стратегия
+// или строгий учет лимитов
+func (r *SelectorRegistry) Configured(teamName string) bool {
+	_, ok := r.teamSelectors[teamName]
+	return ok || r.strictCapacity[teamName]
+}
+
+// RenameTeam переносит очередь round-robin команды на новое имя
+func (r *SelectorRegistry) RenameTeam(oldName, newName string) {
+	r.rotation.rename(oldName, newName)
+}
+
+// lockedRand - потокобезопасная обертка над rand.Rand
+type lockedRand struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newLockedRand(seed int64) *lockedRand {
+	return &lockedRand{rand: rand.New(rand.NewSource(seed))}
+}
+
+func (r *lockedRand) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Intn(n)
+}
+
+func (r *lockedRand) Shuffle(n int, swap func(i, j int)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rand.Shuffle(n, swap)
+}
+
+// randomSelector выбирает ревьюверов равновероятно
+type randomSelector struct {
+	rand *lockedRand
+}
+
+// Select реализует ReviewerSelector
+func (s *randomSelector) Select(_ context.Context, _ string, candidates []*models.User, count int) ([]*models.User, error) {
+	shuffled := make([]*models.User, len(candidates))
+	copy(shuffled, candidates)
+	s.rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return limit(shuffled, count), nil
+}
+
+// rotationCursor - последний выбранный в команде ревьювер
+type rotationCursor struct {
+	username string
+	userID   string
+}
+
+// before сообщает, идет ли курсор раньше user в порядке (username, user_id)
+func (c rotationCursor) before(user *models.User) bool {
+	if c.username != user.Username {
+		return c.username < user.Username
+	}
+	return c.userID < user.UserID
+}
+
+// roundRobinSelector выбирает ревьюверов по очереди внутри команды. Для
+// команды запоминается последний выбранный ревьювер, и следующий выбор
+// начинается с кандидата после него в порядке username. Поэтому очередь не
+// сбивается, когда состав кандидатов меняется между вызовами
+type roundRobinSelector struct {
+	mu      sync.Mutex
+	cursors map[string]rotationCursor
+}
+
+// Select реализует ReviewerSelector
+func (s *roundRobinSelector) Select(_ context.Context, team string, candidates []*models.User, count int) ([]*models.User, error) {
+	if len(candidates) == 0 || count <= 0 {
+		return []*models.User{}, nil
+	}
+
+	sorted := make([]*models.User, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool {
+		return rotationCursor{username: sorted[i].Username, userID: sorted[i].UserID}.before(sorted[j])
+	})
+
+	n := count
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := 0
+	if cursor, ok := s.cursors[team]; ok {
+		start = sort.Search(len(sorted), func(i int) bool {
+			return cursor.before(sorted[i])
+		})
+	}
+
+	selected := make([]*models.User, 0, n)
+	for i := 0; i < n; i++ {
+		selected = append(selected, sorted[(start+i)%len(sorted)])
+	}
+	last := selected[n-1]
+	s.cursors[team] = rotationCursor{username: last.Username, userID: last.UserID}
+	return selected, nil
+}
+
+// rename переносит курсор команды на новое имя
+func (s *roundRobinSelector) rename(oldName, newName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cursor, ok := s.cursors[oldName]; ok {
+		s.cursors[newName] = cursor
+		delete(s.cursors, oldName)
+	}
+}
+
+// leastLoadedSelector выбирает наименее загруженных открытыми ревью кандидатов,
+// при равной загрузке порядок случайный
+type leastLoadedSelector struct {
+	rand   *lockedRand
+	prRepo repository.PullRequestRepository
+}
+
+// Select реализует ReviewerSelector
+func (s *leastLoadedSelector) Select(ctx context.Context, _ string, candidates []*models.User, count int) ([]*models.User, error) {
+	load, err := reviewerLoad(ctx, s.prRepo, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	// Перемешиваем до стабильной сортировки, чтобы случайно разбить ничьи
+	sorted := make([]*models.User, len(candidates))
+	copy(sorted, candidates)
+	s.rand.Shuffle(len(sorted), func(i, j int) {
+		sorted[i], sorted[j] = sorted[j], sorted[i]
+	})
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return load[sorted[i].UserID] < load[sorted[j].UserID]
+	})
+	return limit(sorted, count), nil
+}
+
+// reviewerLoad возвращает число открытых ревью кандидатов из кэша lookup
+// операции, если он есть в контексте, иначе из БД
+func reviewerLoad(ctx context.Context, prRepo repository.PullRequestRepository, candidates []*models.User) (map[string]int, error) {
+	if lookup, ok := ctx.Value(lookupKey{}).(*reviewerLookup); ok {
+		return lookup.openLoad(ctx, candidates)
+	}
+	return openReviewLoad(ctx, prRepo, candidates)
+}
+
+// openReviewLoad возвращает число открытых ревью кандидатов одним запросом
+// по их ID. Кандидаты без команды (например, владельцы кода) учитываются так же
+func openReviewLoad(ctx context.Context, prRepo repository.PullRequestRepository, candidates []*models.User) (map[string]int, error) {
+	userIDs := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		userIDs = append(userIDs, candidate.UserID)
+	}
+
+	load, err := prRepo.CountOpenReviews(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviewer load: %w", err)
+	}
+	return load, nil
+}
+
+// weightedSelector выбирает ревьюверов случайно с учетом весов из конфигурации
+type weightedSelector struct {
+	rand    *lockedRand
+	weights map[string]int
+}
+
+// Select реализует ReviewerSelector
+func (s *weightedSelector) Select(_ context.Context, _ string, candidates []*models.User, count int) ([]*models.User, error) {
+	pool := make([]*models.User, 0, len(candidates))
+	for _, candidate := range candidates {
+		if s.weight(candidate.UserID) > 0 {
+			pool = append(pool, candidate)
+		}
+	}
+
+	selected := make([]*models.User, 0, count)
+	for len(selected) < count && len(pool) > 0 {
+		total := 0
+		for _, candidate := range pool {
+			total += s.weight(candidate.UserID)
+		}
+
+		// Выбор без возвращения: выбранный кандидат убирается из пула
+		point := s.rand.Intn(total)
+		for i, candidate := range pool {
+			point -= s.weight(candidate.UserID)
+			if point < 0 {
+				selected = append(selected, candidate)
+				pool = append(pool[:i], pool[i+1:]...)
+				break
+			}
+		}
+	}
+	return selected, nil
+}
+
+// weight возвращает вес пользователя, по умолчанию 1
+func (s *weightedSelector) weight(userID string) int {
+	if w, ok := s.weights[userID]; ok {
+		return w
+	}
+	return 1
+}
+
+// limit обрезает список до count элементов
+func limit(users []*models.User, count int) []*models.User {
+	if count < 0 {
+		count = 0
+	}
+	if len(users) > count {
+		return users[:count]
+	}
+	return users
+}
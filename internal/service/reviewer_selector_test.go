@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// fakeLoadRepository возвращает число открытых ревью из памяти
+type fakeLoadRepository struct {
+	repository.PullRequestRepository
+	load  map[string]int
+	calls int
+}
+
+func (r *fakeLoadRepository) CountOpenReviews(_ context.Context, userIDs []string) (map[string]int, error) {
+	r.calls++
+	counts := make(map[string]int, len(userIDs))
+	for _, userID := range userIDs {
+		if count, ok := r.load[userID]; ok {
+			counts[userID] = count
+		}
+	}
+	return counts, nil
+}
+
+func TestFilterByCapacityCountsUsersWithoutTeam(t *testing.T) {
+	one := 1
+	candidates := []*models.User{
+		// Владелец кода без команды уже ревьюит PR и достиг лимита
+		{UserID: "owner", Username: "owner", IsActive: true, MaxOpenReviews: &one},
+		{UserID: "alice", Username: "alice", TeamName: "backend", IsActive: true, MaxOpenReviews: &one},
+		{UserID: "bob", Username: "bob", TeamName: "frontend", IsActive: true, MaxOpenReviews: &one},
+	}
+	prRepo := &fakeLoadRepository{load: map[string]int{"owner": 1, "bob": 1}}
+	s := &pullRequestService{prRepo: prRepo}
+	lookup := newReviewerLookup(nil, nil, prRepo, nil, nil, nil)
+
+	available, err := s.filterByCapacity(context.Background(), lookup, candidates)
+	if err != nil {
+		t.Fatalf("filterByCapacity() error = %v", err)
+	}
+	if len(available) != 1 || available[0].UserID != "alice" {
+		t.Errorf("available = %v, want only alice", userIDs(available))
+	}
+	if prRepo.calls != 1 {
+		t.Errorf("load queried %d times, want once for all candidates", prRepo.calls)
+	}
+}
+
+func TestReviewerLookupCachesLoad(t *testing.T) {
+	one := 1
+	alice := &models.User{UserID: "alice", Username: "alice", IsActive: true, MaxOpenReviews: &one}
+	bob := &models.User{UserID: "bob", Username: "bob", IsActive: true, MaxOpenReviews: &one}
+	prRepo := &fakeLoadRepository{load: map[string]int{"alice": 1}}
+	s := &pullRequestService{prRepo: prRepo}
+	lookup := newReviewerLookup(nil, nil, prRepo, nil, nil, nil)
+	ctx := context.Background()
+
+	available, err := s.filterByCapacity(ctx, lookup, []*models.User{alice, bob})
+	if err != nil {
+		t.Fatalf("filterByCapacity() error = %v", err)
+	}
+	if len(available) != 1 || available[0].UserID != "bob" {
+		t.Fatalf("available = %v, want only bob", userIDs(available))
+	}
+
+	// Операция сняла alice с ревью и назначила bob: повторная проверка
+	// учитывает это без обращения к БД
+	lookup.changeLoad("alice", -1)
+	lookup.changeLoad("bob", 1)
+
+	available, err = s.filterByCapacity(ctx, lookup, []*models.User{alice, bob})
+	if err != nil {
+		t.Fatalf("filterByCapacity() error = %v", err)
+	}
+	if len(available) != 1 || available[0].UserID != "alice" {
+		t.Errorf("available = %v, want only alice", userIDs(available))
+	}
+
+	// least_loaded берет загрузку из того же кэша
+	selector := &leastLoadedSelector{rand: newLockedRand(1), prRepo: prRepo}
+	picked, err := selector.Select(withLookup(ctx, lookup), "backend", []*models.User{alice, bob}, 1)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if len(picked) != 1 || picked[0].UserID != "alice" {
+		t.Errorf("picked = %v, want alice", userIDs(picked))
+	}
+
+	if prRepo.calls != 1 {
+		t.Errorf("load queried %d times, want once per operation", prRepo.calls)
+	}
+}
+
+func TestLeastLoadedSelector(t *testing.T) {
+	candidates := []*models.User{member("alice", "backend"), member("bob", "backend"), member("carol", "backend"), member("dave", "backend")}
+	// У dave открытых ревью нет, и в ответе репозитория его нет совсем
+	prRepo := &fakeLoadRepository{load: map[string]int{"alice": 3, "bob": 1, "carol": 1}}
+
+	// Кандидаты упорядочены по загрузке, ничья bob и carol разбивается случайно
+	picked := make(map[string]int)
+	for seed := int64(0); seed < repeatRandom; seed++ {
+		selector := &leastLoadedSelector{rand: newLockedRand(seed), prRepo: prRepo}
+		selected, err := selector.Select(context.Background(), "backend", candidates, 2)
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		got := userIDs(selected)
+		if len(got) != 2 || got[0] != "dave" || (got[1] != "bob" && got[1] != "carol") {
+			t.Fatalf("picked %v, want dave and then bob or carol", got)
+		}
+		picked[got[1]]++
+	}
+	if picked["bob"] == 0 || picked["carol"] == 0 {
+		t.Errorf("tie picks = %v, want both bob and carol", picked)
+	}
+
+	// Самый загруженный выбирается последним
+	selector := &leastLoadedSelector{rand: newLockedRand(1), prRepo: prRepo}
+	selected, err := selector.Select(context.Background(), "backend", candidates, 10)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if got := userIDs(selected); len(got) != 4 || got[0] != "dave" || got[3] != "alice" {
+		t.Errorf("picked %v, want dave first and alice last", got)
+	}
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	user := func(userID string) *models.User {
+		return &models.User{UserID: userID, Username: userID, TeamName: "backend", IsActive: true}
+	}
+	alice, bob, carol, dave := user("alice"), user("bob"), user("carol"), user("dave")
+
+	// Каждый шаг - вызов Select для команды с текущим составом кандидатов
+	steps := []struct {
+		team       string
+		candidates []*models.User
+		count      int
+		want       []string
+	}{
+		// Порядок кандидатов на входе не важен, очередь идет по username
+		{team: "backend", candidates: []*models.User{carol, alice, bob}, count: 1, want: []string{"alice"}},
+		{team: "backend", candidates: []*models.User{alice, bob, carol}, count: 1, want: []string{"bob"}},
+		// bob занят: очередь продолжается после него, а не со сдвигом по индексу
+		{team: "backend", candidates: []*models.User{alice, carol, dave}, count: 1, want: []string{"carol"}},
+		// Курсор переходит через конец списка
+		{team: "backend", candidates: []*models.User{alice, bob, carol, dave}, count: 2, want: []string{"dave", "alice"}},
+		// У другой команды с теми же кандидатами своя очередь
+		{team: "frontend", candidates: []*models.User{alice, bob, carol, dave}, count: 1, want: []string{"alice"}},
+		// Последний выбранный (alice) ушел из команды: следующий после него
+		{team: "backend", candidates: []*models.User{bob, carol, dave}, count: 1, want: []string{"bob"}},
+		{team: "backend", candidates: []*models.User{alice, bob}, count: 5, want: []string{"alice", "bob"}},
+		{team: "backend", candidates: []*models.User{alice, bob}, count: 0, want: []string{}},
+	}
+
+	selector := &roundRobinSelector{cursors: make(map[string]rotationCursor)}
+	for i, step := range steps {
+		picked, err := selector.Select(context.Background(), step.team, step.candidates, step.count)
+		if err != nil {
+			t.Fatalf("step %d: Select() error = %v", i, err)
+		}
+		got := userIDs(picked)
+		if len(got) != len(step.want) {
+			t.Fatalf("step %d: picked %v, want %v", i, got, step.want)
+		}
+		for j := range got {
+			if got[j] != step.want[j] {
+				t.Fatalf("step %d: picked %v, want %v", i, got, step.want)
+			}
+		}
+	}
+}
+
+func TestRoundRobinSelectorSameUsername(t *testing.T) {
+	// Одинаковые username различаются по user_id
+	first := &models.User{UserID: "u1", Username: "alex", TeamName: "backend", IsActive: true}
+	second := &models.User{UserID: "u2", Username: "alex", TeamName: "backend", IsActive: true}
+	candidates := []*models.User{second, first}
+
+	selector := &roundRobinSelector{cursors: make(map[string]rotationCursor)}
+	var got []string
+	for i := 0; i < 4; i++ {
+		picked, err := selector.Select(context.Background(), "backend", candidates, 1)
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		got = append(got, picked[0].UserID)
+	}
+
+	want := []string{"u1", "u2", "u1", "u2"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("picked %v, want %v", got, want)
+		}
+	}
+}
+
+// userIDs возвращает ID пользователей для сообщений об ошибках
+func userIDs(users []*models.User) []string {
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.UserID)
+	}
+	return ids
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// fakeUserRepository хранит пользователей в памяти
+type fakeUserRepository struct {
+	repository.UserRepository
+	users []*models.User
+}
+
+func (r *fakeUserRepository) Get(_ context.Context, userID string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.UserID == userID {
+			return user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *fakeUserRepository) GetActiveTeammates(_ context.Context, teamName, excludeUserID string) ([]*models.User, error) {
+	var teammates []*models.User
+	for _, user := range r.users {
+		if user.TeamName == teamName && user.UserID != excludeUserID && user.IsActive {
+			teammates = append(teammates, user)
+		}
+	}
+	sort.Slice(teammates, func(i, j int) bool {
+		return teammates[i].Username < teammates[j].Username
+	})
+	return teammates, nil
+}
+
+func (r *fakeUserRepository) GetByTeam(_ context.Context, teamName string) ([]*models.User, error) {
+	var members []*models.User
+	for _, user := range r.users {
+		if user.TeamName == teamName {
+			members = append(members, user)
+		}
+	}
+	return members, nil
+}
+
+func (r *fakeUserRepository) Create(_ context.Context, user *models.User) error {
+	if _, err := r.Get(context.Background(), user.UserID); err == nil {
+		return repository.ErrUserExists
+	}
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r *fakeUserRepository) Update(_ context.Context, user *models.User) error {
+	for i, stored := range r.users {
+		if stored.UserID == user.UserID {
+			r.users[i] = user
+			return nil
+		}
+	}
+	return errors.New("user not found")
+}
+
+func (r *fakeUserRepository) SetTeam(ctx context.Context, userID, teamName string) error {
+	user, err := r.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	user.TeamName = teamName
+	return nil
+}
+
+func (r *fakeUserRepository) DeactivateMany(_ context.Context, teamName string, userIDs []string) ([]*models.User, error) {
+	target := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		target[userID] = true
+	}
+	var deactivated []*models.User
+	for _, user := range r.users {
+		if user.TeamName == teamName && target[user.UserID] && user.IsActive {
+			user.IsActive = false
+			deactivated = append(deactivated, user)
+		}
+	}
+	return deactivated, nil
+}
+
+// SetActive заменяет пользователя копией, чтобы ранее прочитанная версия
+// сохранила прежний статус
+func (r *fakeUserRepository) SetActive(_ context.Context, userID string, isActive bool) error {
+	for i, stored := range r.users {
+		if stored.UserID == userID {
+			user := *stored
+			user.IsActive = isActive
+			r.users[i] = &user
+			return nil
+		}
+	}
+	return errors.New("user not found")
+}
+
+// fakeTeamRepository хранит настройки и резервные команды в памяти.
+// Для команд без настроек используется defaults
+type fakeTeamRepository struct {
+	repository.TeamRepository
+	defaults  models.TeamSettings
+	settings  map[string]models.TeamSettings
+	fallbacks map[string][]string
+}
+
+func (r *fakeTeamRepository) GetSettings(_ context.Context, teamName string) (*models.TeamSettings, error) {
+	settings, ok := r.settings[teamName]
+	if !ok {
+		settings = r.defaults
+	}
+	settings.TeamName = teamName
+	return &settings, nil
+}
+
+func (r *fakeTeamRepository) GetFallbackTeams(_ context.Context, teamName string) ([]string, error) {
+	return r.fallbacks[teamName], nil
+}
+
+// fakeRuleRepository возвращает заданные правила назначения
+type fakeRuleRepository struct {
+	repository.RuleRepository
+	rules []*models.ReviewerRule
+}
+
+func (r fakeRuleRepository) ListRules(context.Context) ([]*models.ReviewerRule, error) {
+	return r.rules, nil
+}
+
+// testNow - время, которое видит сервис в тестах выбора ревьюверов
+var testNow = time.Date(2024, 5, 14, 7, 0, 0, 0, time.UTC)
+
+// newReviewerTestService создает сервис для тестов выбора ревьюверов со
+// случайной стратегией и фиксированным временем
+func newReviewerTestService(users *fakeUserRepository, teams *fakeTeamRepository, rules []*models.ReviewerRule) *pullRequestService {
+	selectors, err := NewSelectorRegistry(SelectorConfig{DefaultStrategy: StrategyRandom}, nil)
+	if err != nil {
+		panic(err)
+	}
+	return &pullRequestService{
+		userRepo:  users,
+		teamRepo:  teams,
+		ruleRepo:  fakeRuleRepository{rules: rules},
+		selectors: selectors,
+		clock:     ClockFunc(func() time.Time { return testNow }),
+	}
+}
+
+// member создает активного участника команды
+func member(userID, team string) *models.User {
+	return &models.User{UserID: userID, Username: userID, TeamName: team, IsActive: true}
+}
+
+// choiceIDs возвращает ID выбранных ревьюверов в порядке username
+func choiceIDs(choices []models.ReviewerChoice) []string {
+	ids := reviewerIDs(choices)
+	sort.Strings(ids)
+	return ids
+}
+
+// repeatRandom - сколько раз повторяется случайный выбор в тестах
+const repeatRandom = 20
+
+// fakePullRequestRepository хранит PR с ревьюверами в памяти и запоминает,
+// какие PR блокировались
+type fakePullRequestRepository struct {
+	repository.PullRequestRepository
+	prs    map[string]*models.PullRequest
+	locked []string
+}
+
+func newFakePullRequestRepository(prs ...*models.PullRequest) *fakePullRequestRepository {
+	repo := &fakePullRequestRepository{prs: make(map[string]*models.PullRequest)}
+	for _, pr := range prs {
+		repo.prs[pr.PullRequestID] = pr
+	}
+	return repo
+}
+
+func (r *fakePullRequestRepository) Create(_ context.Context, pr *models.PullRequest) error {
+	if _, ok := r.prs[pr.PullRequestID]; ok {
+		return repository.ErrPRExists
+	}
+	stored := *pr
+	stored.AssignedReviewers = append([]string{}, pr.AssignedReviewers...)
+	r.prs[pr.PullRequestID] = &stored
+	return nil
+}
+
+func (r *fakePullRequestRepository) Get(_ context.Context, prID string) (*models.PullRequest, error) {
+	stored, ok := r.prs[prID]
+	if !ok {
+		return nil, repository.ErrPRNotFound
+	}
+	pr := *stored
+	pr.AssignedReviewers = append([]string{}, stored.AssignedReviewers...)
+	return &pr, nil
+}
+
+func (r *fakePullRequestRepository) Exists(_ context.Context, prID string) (bool, error) {
+	_, ok := r.prs[prID]
+	return ok, nil
+}
+
+func (r *fakePullRequestRepository) GetForUpdate(ctx context.Context, prID string) (*models.PullRequest, error) {
+	r.locked = append(r.locked, prID)
+	return r.Get(ctx, prID)
+}
+
+func (r *fakePullRequestRepository) Update(_ context.Context, pr *models.PullRequest) error {
+	stored, ok := r.prs[pr.PullRequestID]
+	if !ok {
+		return repository.ErrPRNotFound
+	}
+	stored.Status = pr.Status
+	stored.MergedAt = pr.MergedAt
+	stored.ClosedAt = pr.ClosedAt
+	return nil
+}
+
+func (r *fakePullRequestRepository) GetChangedFiles(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *fakePullRequestRepository) AssignReviewer(_ context.Context, prID, reviewerID string) error {
+	r.prs[prID].AssignedReviewers = append(r.prs[prID].AssignedReviewers, reviewerID)
+	return nil
+}
+
+func (r *fakePullRequestRepository) RemoveReviewer(_ context.Context, prID, reviewerID string) error {
+	pr := r.prs[prID]
+	for i, assigned := range pr.AssignedReviewers {
+		if assigned == reviewerID {
+			pr.AssignedReviewers = append(pr.AssignedReviewers[:i], pr.AssignedReviewers[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("reviewer assignment not found")
+}
+
+func (r *fakePullRequestRepository) GetOpenAssignments(_ context.Context, reviewerIDs []string) ([]models.ReviewAssignment, error) {
+	prIDs := make([]string, 0, len(r.prs))
+	for prID := range r.prs {
+		prIDs = append(prIDs, prID)
+	}
+	sort.Strings(prIDs)
+
+	var assignments []models.ReviewAssignment
+	for _, prID := range prIDs {
+		pr := r.prs[prID]
+		if pr.Status != models.StatusOpen {
+			continue
+		}
+		for _, reviewerID := range pr.AssignedReviewers {
+			for _, target := range reviewerIDs {
+				if reviewerID == target {
+					assignments = append(assignments, models.ReviewAssignment{PullRequestID: prID, ReviewerID: reviewerID})
+				}
+			}
+		}
+	}
+	return assignments, nil
+}
+
+func (r *fakePullRequestRepository) CountOpenReviews(_ context.Context, userIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(userIDs))
+	for _, pr := range r.prs {
+		if pr.Status != models.StatusOpen {
+			continue
+		}
+		for _, reviewerID := range pr.AssignedReviewers {
+			counts[reviewerID]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *fakePullRequestRepository) IsReviewerAssigned(_ context.Context, prID, reviewerID string) (bool, error) {
+	for _, assigned := range r.prs[prID].AssignedReviewers {
+		if assigned == reviewerID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeAssignmentEventRepository хранит историю назначений в памяти
+type fakeAssignmentEventRepository struct {
+	events []*models.AssignmentEvent
+}
+
+func (r *fakeAssignmentEventRepository) Add(_ context.Context, event *models.AssignmentEvent) error {
+	event.ID = int64(len(r.events) + 1)
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *fakeAssignmentEventRepository) GetByPullRequest(_ context.Context, prID string) ([]*models.AssignmentEvent, error) {
+	var events []*models.AssignmentEvent
+	for _, event := range r.events {
+		if event.PullRequestID == prID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// passthroughTransactor выполняет fn без транзакции
+type passthroughTransactor struct{}
+
+func (passthroughTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakeEventPublisher запоминает опубликованные события
+type fakeEventPublisher struct {
+	events []*models.WebhookEvent
+}
+
+func (p *fakeEventPublisher) Publish(_ context.Context, event *models.WebhookEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+// newAssignmentTestService создает сервис PR над репозиториями в памяти
+// со стратегией round_robin, чтобы выбор ревьюверов был предсказуемым
+func newAssignmentTestService(t *testing.T, users *fakeUserRepository, teams *fakeTeamRepository, prRepo *fakePullRequestRepository) *pullRequestService {
+	t.Helper()
+
+	selectors, err := NewSelectorRegistry(SelectorConfig{DefaultStrategy: StrategyRoundRobin}, prRepo)
+	if err != nil {
+		t.Fatalf("failed to create selectors: %v", err)
+	}
+	return &pullRequestService{
+		userRepo:  users,
+		prRepo:    prRepo,
+		teamRepo:  teams,
+		eventRepo: &fakeAssignmentEventRepository{},
+		ruleRepo:  fakeRuleRepository{},
+		tx:        passthroughTransactor{},
+		selectors: selectors,
+		events:    &fakeEventPublisher{},
+		clock:     ClockFunc(func() time.Time { return testNow }),
+	}
+}
+
+// openPR создает открытый PR автора с назначенными ревьюверами
+func openPR(prID, authorID string, reviewers ...string) *models.PullRequest {
+	return &models.PullRequest{PullRequestID: prID, AuthorID: authorID, Status: models.StatusOpen, AssignedReviewers: reviewers}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// RuleService определяет интерфейс для управления правилами назначения ревьюверов
+type RuleService interface {
+	CreateRule(ctx context.Context, rule *models.ReviewerRule) (*models.ReviewerRule, error)
+	UpdateRule(ctx context.Context, rule *models.ReviewerRule) (*models.ReviewerRule, error)
+	DeleteRule(ctx context.Context, id int64) error
+	ListRules(ctx context.Context) ([]*models.ReviewerRule, error)
+}
+
+// ruleService реализует RuleService
+type ruleService struct {
+	ruleRepo repository.RuleRepository
+	userRepo repository.UserRepository
+}
+
+// NewRuleService создает новый сервис правил назначения ревьюверов
+func NewRuleService(ruleRepo repository.RuleRepository, userRepo repository.UserRepository) RuleService {
+	return &ruleService{
+		ruleRepo: ruleRepo,
+		userRepo: userRepo,
+	}
+}
+
+// CreateRule проверяет и создает правило. Оно применяется к следующим назначениям
+func (s *ruleService) CreateRule(ctx context.Context, rule *models.ReviewerRule) (*models.ReviewerRule, error) {
+	if err := s.validateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.CreateRule(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to create reviewer rule: %w", err)
+	}
+	return rule, nil
+}
+
+// UpdateRule проверяет и заменяет содержимое правила
+func (s *ruleService) UpdateRule(ctx context.Context, rule *models.ReviewerRule) (*models.ReviewerRule, error) {
+	if err := s.validateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.UpdateRule(ctx, rule); err != nil {
+		return nil, ErrReviewerRuleNotFound
+	}
+	return rule, nil
+}
+
+// DeleteRule удаляет правило
+func (s *ruleService) DeleteRule(ctx context.Context, id int64) error {
+	if err := s.ruleRepo.DeleteRule(ctx, id); err != nil {
+		return ErrReviewerRuleNotFound
+	}
+	return nil
+}
+
+// ListRules возвращает все правила
+func (s *ruleService) ListRules(ctx context.Context) ([]*models.ReviewerRule, error) {
+	rules, err := s.ruleRepo.ListRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviewer rules: %w", err)
+	}
+	return rules, nil
+}
+
+// validateRule проверяет тип правила, что пользователи существуют и различны
+// и что такого правила еще нет
+func (s *ruleService) validateRule(ctx context.Context, rule *models.ReviewerRule) error {
+	if rule.Kind != models.RuleExclude && rule.Kind != models.RulePair {
+		return ErrInvalidReviewerRule
+	}
+	if rule.UserID == rule.TargetID {
+		return ErrInvalidReviewerRule
+	}
+
+	for _, userID := range []string{rule.UserID, rule.TargetID} {
+		if _, err := s.userRepo.Get(ctx, userID); err != nil {
+			return ErrUserNotFound
+		}
+	}
+
+	rules, err := s.ruleRepo.ListRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list reviewer rules: %w", err)
+	}
+	for _, existing := range rules {
+		if existing.ID != rule.ID && existing.Kind == rule.Kind &&
+			existing.UserID == rule.UserID && existing.TargetID == rule.TargetID {
+			return ErrInvalidReviewerRule
+		}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package service
+
+import "github.com/zazaza5818/pr-reviewer-service/internal/models"
+
+// seniorityRank упорядочивает уровни пользователей. Пользователь без уровня
+// не удовлетворяет ни одному требованию
+var seniorityRank = map[models.Seniority]int{
+	models.SeniorityJunior: 1,
+	models.SeniorityMiddle: 2,
+	models.SenioritySenior: 3,
+}
+
+// validSeniority проверяет, что уровень известен
+func validSeniority(seniority models.Seniority) bool {
+	return seniorityRank[seniority] > 0
+}
+
+// meetsSeniority проверяет, что уровень пользователя не ниже level
+func meetsSeniority(user *models.User, level models.Seniority) bool {
+	rank := seniorityRank[user.Seniority]
+	return rank > 0 && rank >= seniorityRank[level]
+}
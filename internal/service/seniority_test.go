@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// withSeniority задает уровень пользователя
+func withSeniority(user *models.User, seniority models.Seniority) *models.User {
+	user.Seniority = seniority
+	return user
+}
+
+func TestInitialReviewersSeniorityQuota(t *testing.T) {
+	author := member("author", "backend")
+	users := &fakeUserRepository{users: []*models.User{
+		author,
+		withSeniority(member("junior1", "backend"), models.SeniorityJunior),
+		withSeniority(member("junior2", "backend"), models.SeniorityJunior),
+		withSeniority(member("middle", "backend"), models.SeniorityMiddle),
+		withSeniority(member("senior", "backend"), models.SenioritySenior),
+		withSeniority(member("lead", "backend"), models.SenioritySenior),
+	}}
+
+	tests := []struct {
+		name     string
+		settings models.TeamSettings
+		// seniors - сколько выбранных должны быть уровня SENIOR
+		seniors int
+		wantLen int
+		wantErr error
+	}{
+		{
+			name:     "one senior among two",
+			settings: models.TeamSettings{MaxReviewers: 2, RequiredSeniority: models.SenioritySenior, RequiredSeniorityCount: 1},
+			seniors:  1,
+			wantLen:  2,
+		},
+		{
+			name:     "quota equals max reviewers",
+			settings: models.TeamSettings{MaxReviewers: 2, RequiredSeniority: models.SenioritySenior, RequiredSeniorityCount: 2},
+			seniors:  2,
+			wantLen:  2,
+		},
+		{
+			// Такие настройки может задать только политика; остальные не добираются
+			name:     "quota above max reviewers",
+			settings: models.TeamSettings{MaxReviewers: 1, RequiredSeniority: models.SenioritySenior, RequiredSeniorityCount: 2},
+			seniors:  2,
+			wantLen:  2,
+		},
+		{
+			name:     "not enough seniors",
+			settings: models.TeamSettings{MaxReviewers: 3, RequiredSeniority: models.SenioritySenior, RequiredSeniorityCount: 3},
+			wantErr:  ErrNoSeniorCandidate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newReviewerTestService(users, &fakeTeamRepository{defaults: tt.settings}, nil)
+
+			for i := 0; i < repeatRandom; i++ {
+				choices, _, err := s.initialReviewers(context.Background(), author, nil, nil)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("initialReviewers() error = %v, want %v", err, tt.wantErr)
+				}
+				if err != nil {
+					return
+				}
+				if len(choices) != tt.wantLen {
+					t.Fatalf("picked %v, want %d reviewers", choiceIDs(choices), tt.wantLen)
+				}
+
+				seniors := 0
+				for _, choice := range choices {
+					user, _ := users.Get(context.Background(), choice.ReviewerID)
+					hasReason := false
+					for _, reason := range choice.Reasons {
+						hasReason = hasReason || reason == "required seniority SENIOR"
+					}
+					if hasReason {
+						seniors++
+						if user.Seniority != models.SenioritySenior {
+							t.Errorf("%s picked for seniority but is %s", user.UserID, user.Seniority)
+						}
+					}
+				}
+				if seniors != tt.seniors {
+					t.Errorf("picked %v with %d seniors, want %d", choiceIDs(choices), seniors, tt.seniors)
+				}
+			}
+		})
+	}
+}
+
+func TestReplacementSeniority(t *testing.T) {
+	author := member("author", "backend")
+	users := &fakeUserRepository{users: []*models.User{
+		author,
+		withSeniority(member("junior", "backend"), models.SeniorityJunior),
+		withSeniority(member("middle", "backend"), models.SeniorityMiddle),
+		withSeniority(member("senior", "backend"), models.SenioritySenior),
+		withSeniority(member("lead", "backend"), models.SenioritySenior),
+	}}
+	required := models.TeamSettings{MaxReviewers: 2, RequiredSeniority: models.SenioritySenior, RequiredSeniorityCount: 1}
+
+	tests := []struct {
+		name      string
+		settings  models.TeamSettings
+		reviewers []string
+		old       string
+		want      models.Seniority
+	}{
+		{name: "only senior leaves", settings: required, reviewers: []string{"senior", "junior"}, old: "senior", want: models.SenioritySenior},
+		{name: "another senior remains", settings: required, reviewers: []string{"senior", "lead"}, old: "senior"},
+		{name: "junior leaves", settings: required, reviewers: []string{"senior", "junior"}, old: "junior"},
+		{name: "no requirement", settings: models.TeamSettings{MaxReviewers: 2}, reviewers: []string{"senior", "junior"}, old: "senior"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newReviewerTestService(users, &fakeTeamRepository{defaults: tt.settings}, nil)
+			pr := &models.PullRequest{PullRequestID: "pr-1", AuthorID: "author", AssignedReviewers: tt.reviewers}
+			old, _ := users.Get(context.Background(), tt.old)
+
+			got, err := s.replacementSeniority(context.Background(), s.newLookup(), pr, author, old)
+			if err != nil {
+				t.Fatalf("replacementSeniority() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("replacementSeniority() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickReviewersRequiredSeniority(t *testing.T) {
+	users := &fakeUserRepository{users: []*models.User{
+		withSeniority(member("junior", "backend"), models.SeniorityJunior),
+		withSeniority(member("middle", "backend"), models.SeniorityMiddle),
+		withSeniority(member("senior", "backend"), models.SenioritySenior),
+	}}
+	s := newReviewerTestService(users, &fakeTeamRepository{defaults: models.TeamSettings{MaxReviewers: 2}}, nil)
+
+	tests := []struct {
+		level models.Seniority
+		want  []string
+	}{
+		// Уровень выше требуемого тоже подходит
+		{level: models.SeniorityMiddle, want: []string{"middle", "senior"}},
+		{level: models.SenioritySenior, want: []string{"senior"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.level), func(t *testing.T) {
+			picked, _, err := s.pickReviewers(context.Background(), reviewerRequest{
+				lookup:       s.newLookup(),
+				policyTeam:   "backend",
+				minSeniority: tt.level,
+				teams:        []string{"backend"},
+				exclude:      map[string]bool{},
+				count:        3,
+			})
+			if err != nil {
+				t.Fatalf("pickReviewers() error = %v", err)
+			}
+			var got []string
+			for _, reviewer := range picked {
+				got = append(got, reviewer.user.UserID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("picked %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// maxTags - сколько навыков у пользователя или тегов у PR можно задать
+const maxTags = 20
+
+// tagPattern - допустимый навык или тег: go, postgres, k8s, c++, ci-cd
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9+#._-]{0,31}$`)
+
+// normalizeTags приводит теги к нижнему регистру и проверяет их.
+// Повторы недопустимы, пустой список допустим
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) > maxTags {
+		return nil, ErrInvalidTags
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) || seen[tag] {
+			return nil, ErrInvalidTags
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+// matchedSkills возвращает теги PR, которые есть среди навыков пользователя
+func matchedSkills(user *models.User, tags []string) []string {
+	if len(tags) == 0 || len(user.Skills) == 0 {
+		return nil
+	}
+
+	skills := make(map[string]bool, len(user.Skills))
+	for _, skill := range user.Skills {
+		skills[skill] = true
+	}
+
+	var matched []string
+	for _, tag := range tags {
+		if skills[tag] {
+			matched = append(matched, tag)
+		}
+	}
+	return matched
+}
+
+// groupBySkills разбивает кандидатов на группы по убыванию числа навыков,
+// совпавших с тегами PR, сохраняя порядок внутри групп. Без тегов все
+// кандидаты попадают в одну группу
+func groupBySkills(candidates []*models.User, tags []string) [][]*models.User {
+	if len(tags) == 0 {
+		return [][]*models.User{candidates}
+	}
+
+	byScore := make(map[int][]*models.User)
+	for _, candidate := range candidates {
+		score := len(matchedSkills(candidate, tags))
+		byScore[score] = append(byScore[score], candidate)
+	}
+
+	scores := make([]int, 0, len(byScore))
+	for score := range byScore {
+		scores = append(scores, score)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(scores)))
+
+	groups := make([][]*models.User, 0, len(scores))
+	for _, score := range scores {
+		groups = append(groups, byScore[score])
+	}
+	return groups
+}
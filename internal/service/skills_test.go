@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// withSkills задает навыки пользователя
+func withSkills(user *models.User, skills ...string) *models.User {
+	user.Skills = skills
+	return user
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr error
+	}{
+		{name: "empty", tags: nil, want: []string{}},
+		{name: "lower case and trimmed", tags: []string{" Go", "C++", "ci-cd"}, want: []string{"go", "c++", "ci-cd"}},
+		{name: "duplicate after normalization", tags: []string{"go", "GO"}, wantErr: ErrInvalidTags},
+		{name: "invalid character", tags: []string{"go lang"}, wantErr: ErrInvalidTags},
+		{name: "empty tag", tags: []string{""}, wantErr: ErrInvalidTags},
+		{name: "too many", tags: make([]string, maxTags+1), wantErr: ErrInvalidTags},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeTags(tt.tags)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("normalizeTags() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupBySkills(t *testing.T) {
+	alice := withSkills(member("alice", "backend"), "go", "postgres")
+	bob := withSkills(member("bob", "backend"), "go")
+	carol := member("carol", "backend")
+	dave := withSkills(member("dave", "backend"), "postgres", "k8s")
+	candidates := []*models.User{carol, bob, alice, dave}
+
+	tests := []struct {
+		name string
+		tags []string
+		want [][]string
+	}{
+		{name: "no tags", tags: nil, want: [][]string{{"carol", "bob", "alice", "dave"}}},
+		{name: "by matched count", tags: []string{"go", "postgres"}, want: [][]string{{"alice"}, {"bob", "dave"}, {"carol"}}},
+		{name: "no matches", tags: []string{"rust"}, want: [][]string{{"carol", "bob", "alice", "dave"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]string
+			for _, group := range groupBySkills(candidates, tt.tags) {
+				var ids []string
+				for _, user := range group {
+					ids = append(ids, user.UserID)
+				}
+				got = append(got, ids)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupBySkills() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitialReviewersExplainsSkills(t *testing.T) {
+	author := member("author", "backend")
+	users := &fakeUserRepository{users: []*models.User{
+		author,
+		withSkills(member("alice", "backend"), "postgres", "go"),
+		withSkills(member("bob", "backend"), "go"),
+		member("carol", "backend"),
+		withSkills(member("dave", "frontend"), "go", "postgres"),
+	}}
+	teams := &fakeTeamRepository{defaults: models.TeamSettings{MaxReviewers: 2}}
+	s := newReviewerTestService(users, teams, nil)
+
+	// Кандидаты с большим числом совпавших навыков выбираются первыми,
+	// а совпавшие навыки перечисляются в порядке тегов PR
+	want := []models.ReviewerChoice{
+		{ReviewerID: "alice", Reasons: []string{"member of team backend", "matched skills: go, postgres"}},
+		{ReviewerID: "bob", Reasons: []string{"member of team backend", "matched skills: go"}},
+	}
+	for i := 0; i < repeatRandom; i++ {
+		choices, _, err := s.initialReviewers(context.Background(), author, nil, []string{"go", "postgres"})
+		if err != nil {
+			t.Fatalf("initialReviewers() error = %v", err)
+		}
+		if !reflect.DeepEqual(choices, want) {
+			t.Fatalf("choices = %+v, want %+v", choices, want)
+		}
+	}
+
+	// Без тегов причиной остается только команда
+	choices, _, err := s.initialReviewers(context.Background(), author, nil, nil)
+	if err != nil {
+		t.Fatalf("initialReviewers() error = %v", err)
+	}
+	for _, choice := range choices {
+		if want := []string{"member of team backend"}; !reflect.DeepEqual(choice.Reasons, want) {
+			t.Errorf("reasons of %s = %v, want %v", choice.ReviewerID, choice.Reasons, want)
+		}
+	}
+}
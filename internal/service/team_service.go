@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/zazaza5818/pr-reviewer-service/internal/models"
@@ -12,76 +13,411 @@ import (
 type TeamService interface {
 	CreateTeam(ctx context.Context, team *models.Team) error
 	GetTeam(ctx context.Context, teamName string) (*models.Team, error)
+	GetSettings(ctx context.Context, teamName string) (*models.TeamSettings, error)
+	UpdateSettings(ctx context.Context, update *models.TeamSettingsUpdate) (*models.TeamSettings, error)
+	GetFallbackTeams(ctx context.Context, teamName string) ([]string, error)
+	SetFallbackTeams(ctx context.Context, teamName string, fallbackTeams []string) ([]string, error)
+	AddMember(ctx context.Context, teamName string, member models.TeamMember) (*models.Team, error)
+	RemoveMember(ctx context.Context, teamName, userID string) (*models.ReassignmentReport, error)
+	MoveMember(ctx context.Context, teamName, userID, newTeamName string) (*models.ReassignmentReport, error)
+	RenameTeam(ctx context.Context, teamName, newTeamName string) (*models.Team, error)
+	DeactivateTeam(ctx context.Context, teamName string) (*models.ReassignmentReport, error)
+	DeactivateMembers(ctx context.Context, teamName string, userIDs []string) ([]*models.User, *models.ReassignmentReport, error)
 }
 
+// maxReviewersLimit - верхняя граница max_reviewers в настройках команды
+const maxReviewersLimit = 10
+
 // teamService реализует TeamService
 type teamService struct {
-	teamRepo repository.TeamRepository
-	userRepo repository.UserRepository
+	teamRepo  repository.TeamRepository
+	userRepo  repository.UserRepository
+	tx        repository.Transactor
+	prService PullRequestService
+	selectors *SelectorRegistry
+	policies  *PolicySet
+	events    EventPublisher
+	clock     Clock
 }
 
 // NewTeamService создает новый сервис для работы с командами
 func NewTeamService(
 	teamRepo repository.TeamRepository,
 	userRepo repository.UserRepository,
+	tx repository.Transactor,
+	prService PullRequestService,
+	selectors *SelectorRegistry,
+	policies *PolicySet,
+	events EventPublisher,
+	clock Clock,
 ) TeamService {
 	return &teamService{
-		teamRepo: teamRepo,
-		userRepo: userRepo,
+		teamRepo:  teamRepo,
+		userRepo:  userRepo,
+		tx:        tx,
+		prService: prService,
+		selectors: selectors,
+		policies:  policies,
+		events:    events,
+		clock:     clock,
 	}
 }
 
-// CreateTeam создает команду с участниками
+// CreateTeam создает команду с участниками. Команда и все участники
+// записываются в одной транзакции: при ошибке не сохраняется ничего
 func (s *teamService) CreateTeam(ctx context.Context, team *models.Team) error {
-	// Проверяем, существует ли команда
-	exists, err := s.teamRepo.Exists(ctx, team.TeamName)
+	if err := s.teamRepo.CreateWithMembers(ctx, team); err != nil {
+		if errors.Is(err, repository.ErrTeamExists) {
+			return ErrTeamExists
+		}
+		if errors.Is(err, repository.ErrMemberOfAnotherTeam) {
+			return ErrMemberOfAnotherTeam
+		}
+		return fmt.Errorf("failed to create team: %w", err)
+	}
+
+	return nil
+}
+
+// GetTeam возвращает команду с участниками
+func (s *teamService) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
+	team, err := s.teamRepo.Get(ctx, teamName)
 	if err != nil {
-		return fmt.Errorf("failed to check team existence: %w", err)
+		return nil, ErrTeamNotFound
 	}
-	if exists {
-		return ErrTeamExists
+	return team, nil
+}
+
+// GetSettings возвращает действующие настройки назначения ревьюверов
+// команды: сохраненные настройки с учетом политики команды
+func (s *teamService) GetSettings(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+	settings, err := s.teamRepo.GetSettings(ctx, teamName)
+	if err != nil {
+		return nil, ErrTeamNotFound
 	}
+	return applyPolicy(settings, s.policies.ForTeam(teamName)), nil
+}
 
-	// Создаем команду
-	if err := s.teamRepo.Create(ctx, team); err != nil {
-		return fmt.Errorf("failed to create team: %w", err)
+// UpdateSettings применяет изменение к сохраненным настройкам команды,
+// проверяет и сохраняет результат. Незаданные в update поля не меняются.
+// Настройки проверяются и сами по себе, и вместе с политикой команды, чтобы
+// не сохранить значения, с которыми действующие настройки станут несогласованными
+func (s *teamService) UpdateSettings(ctx context.Context, update *models.TeamSettingsUpdate) (*models.TeamSettings, error) {
+	var updated *models.TeamSettings
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		exists, err := s.teamRepo.Exists(ctx, update.TeamName)
+		if err != nil {
+			return fmt.Errorf("failed to check team existence: %w", err)
+		}
+		if !exists {
+			return ErrTeamNotFound
+		}
+
+		stored, err := s.teamRepo.GetSettings(ctx, update.TeamName)
+		if err != nil {
+			return fmt.Errorf("failed to get team settings: %w", err)
+		}
+		settings := update.Apply(*stored)
+		if settings.RequiredSeniorityCount == 0 {
+			settings.RequiredSeniority = ""
+		}
+		if err := validateSettings(&settings); err != nil {
+			return err
+		}
+		effective := applyPolicy(&settings, s.policies.ForTeam(settings.TeamName))
+		if err := validateSettings(effective); err != nil {
+			return err
+		}
+
+		if err := s.teamRepo.UpsertSettings(ctx, &settings); err != nil {
+			return fmt.Errorf("failed to update team settings: %w", err)
+		}
+		updated = effective
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return updated, nil
+}
+
+// validateSettings проверяет согласованность настроек команды. Используется
+// и для настроек из БД, и для настроек с учетом политики команды
+func validateSettings(settings *models.TeamSettings) error {
+	if settings.MinReviewers < 0 || settings.MaxReviewers < settings.MinReviewers || settings.MaxReviewers > maxReviewersLimit ||
+		settings.RequiredApprovals < 0 || settings.RequiredApprovals > settings.MaxReviewers {
+		return ErrInvalidSettings
+	}
+	// Требование к уровню ревьюверов должно помещаться в max_reviewers
+	if settings.RequiredSeniorityCount < 0 || settings.RequiredSeniorityCount > settings.MaxReviewers {
+		return ErrInvalidSettings
+	}
+	if settings.RequiredSeniorityCount > 0 && !validSeniority(settings.RequiredSeniority) {
+		return ErrInvalidSettings
+	}
+	return nil
+}
+
+// GetFallbackTeams возвращает резервные команды для поиска ревьюверов
+func (s *teamService) GetFallbackTeams(ctx context.Context, teamName string) ([]string, error) {
+	exists, err := s.teamRepo.Exists(ctx, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check team existence: %w", err)
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+
+	teams, err := s.teamRepo.GetFallbackTeams(ctx, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fallback teams: %w", err)
+	}
+	if teams == nil {
+		teams = []string{}
+	}
+	return teams, nil
+}
+
+// SetFallbackTeams задает упорядоченный список резервных команд
+func (s *teamService) SetFallbackTeams(ctx context.Context, teamName string, fallbackTeams []string) ([]string, error) {
+	exists, err := s.teamRepo.Exists(ctx, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check team existence: %w", err)
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+
+	seen := make(map[string]bool, len(fallbackTeams))
+	for _, fallbackTeam := range fallbackTeams {
+		if fallbackTeam == teamName || seen[fallbackTeam] {
+			return nil, ErrInvalidFallback
+		}
+		seen[fallbackTeam] = true
+
+		exists, err := s.teamRepo.Exists(ctx, fallbackTeam)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check team existence: %w", err)
+		}
+		if !exists {
+			return nil, ErrInvalidFallback
+		}
+	}
+
+	if err := s.teamRepo.SetFallbackTeams(ctx, teamName, fallbackTeams); err != nil {
+		return nil, fmt.Errorf("failed to set fallback teams: %w", err)
+	}
+
+	return s.GetFallbackTeams(ctx, teamName)
+}
+
+// AddMember добавляет в команду нового пользователя или пользователя без команды.
+// Для участника этой же команды обновляет его данные. Участника другой команды
+// нужно переводить через MoveMember, чтобы переназначить его ревью
+func (s *teamService) AddMember(ctx context.Context, teamName string, member models.TeamMember) (*models.Team, error) {
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		exists, err := s.teamRepo.Exists(ctx, teamName)
+		if err != nil {
+			return fmt.Errorf("failed to check team existence: %w", err)
+		}
+		if !exists {
+			return ErrTeamNotFound
+		}
 
-	// Создаем или обновляем участников
-	for _, member := range team.Members {
 		user := &models.User{
-			UserID:   member.UserID,
-			Username: member.Username,
-			TeamName: team.TeamName,
-			IsActive: member.IsActive,
+			UserID:         member.UserID,
+			Username:       member.Username,
+			TeamName:       teamName,
+			IsActive:       member.IsActive,
+			MaxOpenReviews: member.MaxOpenReviews,
 		}
 
-		// Пытаемся получить существующего пользователя
-		existingUser, err := s.userRepo.Get(ctx, member.UserID)
+		existing, err := s.userRepo.Get(ctx, member.UserID)
 		if err != nil {
-			// Если пользователь не найден, создаем нового
 			if err := s.userRepo.Create(ctx, user); err != nil {
+				if errors.Is(err, repository.ErrUserExists) {
+					return ErrMemberOfAnotherTeam
+				}
 				return fmt.Errorf("failed to create user %s: %w", member.UserID, err)
 			}
-		} else {
-			// Если пользователь существует, обновляем его
-			existingUser.Username = user.Username
-			existingUser.TeamName = user.TeamName
-			existingUser.IsActive = user.IsActive
-			if err := s.userRepo.Update(ctx, existingUser); err != nil {
-				return fmt.Errorf("failed to update user %s: %w", member.UserID, err)
+			return nil
+		}
+
+		if existing.TeamName != "" && existing.TeamName != teamName {
+			return ErrMemberOfAnotherTeam
+		}
+		// Незаданный в запросе лимит ревью не сбрасывает сохраненный
+		if user.MaxOpenReviews == nil {
+			user.MaxOpenReviews = existing.MaxOpenReviews
+		}
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to update user %s: %w", member.UserID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetTeam(ctx, teamName)
+}
+
+// RemoveMember исключает пользователя из команды. Его открытые ревью
+// переназначаются по правилам ReassignReviewer до исключения, пока
+// замену еще можно искать в его команде. PR, для которых замены нет,
+// остаются за ним
+func (s *teamService) RemoveMember(ctx context.Context, teamName, userID string) (*models.ReassignmentReport, error) {
+	return s.changeMemberTeam(ctx, teamName, userID, "", "reviewer removed from team")
+}
+
+// MoveMember переводит пользователя в другую команду, переназначая его
+// открытые ревью так же, как RemoveMember
+func (s *teamService) MoveMember(ctx context.Context, teamName, userID, newTeamName string) (*models.ReassignmentReport, error) {
+	return s.changeMemberTeam(ctx, teamName, userID, newTeamName, "reviewer moved to another team")
+}
+
+// changeMemberTeam переназначает открытые ревью участника команды teamName
+// и переводит его в newTeamName (пустое имя исключает из команды)
+func (s *teamService) changeMemberTeam(ctx context.Context, teamName, userID, newTeamName, reason string) (*models.ReassignmentReport, error) {
+	var report *models.ReassignmentReport
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.Get(ctx, userID)
+		if err != nil {
+			return ErrUserNotFound
+		}
+		if user.TeamName != teamName {
+			return ErrNotTeamMember
+		}
+
+		if newTeamName != "" {
+			exists, err := s.teamRepo.Exists(ctx, newTeamName)
+			if err != nil {
+				return fmt.Errorf("failed to check team existence: %w", err)
+			}
+			if !exists {
+				return ErrTeamNotFound
 			}
 		}
+
+		report, err = s.prService.ReassignOpenReviews(ctx, []string{userID}, reason)
+		if err != nil {
+			return err
+		}
+
+		if err := s.userRepo.SetTeam(ctx, userID, newTeamName); err != nil {
+			return fmt.Errorf("failed to change user team: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return report, nil
 }
 
-// GetTeam возвращает команду с участниками
-func (s *teamService) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
-	team, err := s.teamRepo.Get(ctx, teamName)
+// RenameTeam переименовывает команду вместе с ее настройками и резервными
+// командами. Политики и настройки стратегий из конфигурации привязаны к имени
+// команды и при переименовании потерялись бы или достались другой команде,
+// поэтому такие команды не переименовываются, пока конфигурацию не обновят.
+// Очередь round-robin переносится на новое имя
+func (s *teamService) RenameTeam(ctx context.Context, teamName, newTeamName string) (*models.Team, error) {
+	for _, name := range []string{teamName, newTeamName} {
+		if s.policies.ForTeam(name) != nil || s.selectors.Configured(name) {
+			return nil, ErrTeamConfigured
+		}
+	}
+
+	if err := s.teamRepo.Rename(ctx, teamName, newTeamName); err != nil {
+		if errors.Is(err, repository.ErrTeamExists) {
+			return nil, ErrTeamExists
+		}
+		if errors.Is(err, repository.ErrTeamNotFound) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("failed to rename team: %w", err)
+	}
+	s.selectors.RenameTeam(teamName, newTeamName)
+
+	return s.GetTeam(ctx, newTeamName)
+}
+
+// DeactivateTeam деактивирует всех участников команды и переназначает их
+// открытые ревью
+func (s *teamService) DeactivateTeam(ctx context.Context, teamName string) (*models.ReassignmentReport, error) {
+	_, report, err := s.DeactivateMembers(ctx, teamName, nil)
+	return report, err
+}
+
+// DeactivateMembers деактивирует участников команды одним запросом и
+// переназначает их открытые ревью на оставшихся активных пользователей.
+// Пустой userIDs означает всю команду. Участники деактивируются до
+// переназначения, чтобы замена не выбиралась среди них самих.
+// Возвращает пользователей, чей статус изменился
+func (s *teamService) DeactivateMembers(ctx context.Context, teamName string, userIDs []string) ([]*models.User, *models.ReassignmentReport, error) {
+	var deactivated []*models.User
+	var report *models.ReassignmentReport
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		exists, err := s.teamRepo.Exists(ctx, teamName)
+		if err != nil {
+			return fmt.Errorf("failed to check team existence: %w", err)
+		}
+		if !exists {
+			return ErrTeamNotFound
+		}
+
+		members, err := s.userRepo.GetByTeam(ctx, teamName)
+		if err != nil {
+			return fmt.Errorf("failed to get team members: %w", err)
+		}
+
+		targetIDs := userIDs
+		reason := "team members deactivated"
+		if len(targetIDs) == 0 {
+			reason = "team deactivated"
+			targetIDs = make([]string, 0, len(members))
+			for _, member := range members {
+				targetIDs = append(targetIDs, member.UserID)
+			}
+		} else {
+			isMember := make(map[string]bool, len(members))
+			for _, member := range members {
+				isMember[member.UserID] = true
+			}
+			for _, userID := range targetIDs {
+				if !isMember[userID] {
+					return ErrNotTeamMember
+				}
+			}
+		}
+		if len(targetIDs) == 0 {
+			report = &models.ReassignmentReport{
+				Reassigned:  []models.ReviewReassignment{},
+				NoCandidate: []models.ReviewReassignment{},
+			}
+			return nil
+		}
+
+		deactivated, err = s.userRepo.DeactivateMany(ctx, teamName, targetIDs)
+		if err != nil {
+			return fmt.Errorf("failed to deactivate team members: %w", err)
+		}
+		for _, user := range deactivated {
+			if err := emit(ctx, s.events, s.clock, models.EventUserDeactivated, map[string]interface{}{"user": user}); err != nil {
+				return err
+			}
+		}
+
+		// Открытые ревью переназначаются и у тех, кто уже был неактивен
+		report, err = s.prService.ReassignOpenReviews(ctx, targetIDs, reason)
+		return err
+	})
 	if err != nil {
-		return nil, ErrTeamNotFound
+		return nil, nil, err
 	}
-	return team, nil
+
+	if deactivated == nil {
+		deactivated = []*models.User{}
+	}
+	return deactivated, report, nil
 }
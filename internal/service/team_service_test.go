@@ -0,0 +1,629 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// fakeTeamSettingsRepository хранит настройки одной команды в памяти
+type fakeTeamSettingsRepository struct {
+	repository.TeamRepository
+	settings models.TeamSettings
+	saved    bool
+}
+
+func (r *fakeTeamSettingsRepository) Exists(_ context.Context, teamName string) (bool, error) {
+	return teamName == r.settings.TeamName, nil
+}
+
+func (r *fakeTeamSettingsRepository) GetSettings(context.Context, string) (*models.TeamSettings, error) {
+	settings := r.settings
+	return &settings, nil
+}
+
+func (r *fakeTeamSettingsRepository) UpsertSettings(_ context.Context, settings *models.TeamSettings) error {
+	r.settings = *settings
+	r.saved = true
+	return nil
+}
+
+// intPtr возвращает указатель на n
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestUpdateSettings(t *testing.T) {
+	stored := models.TeamSettings{
+		TeamName:               "backend",
+		MinReviewers:           1,
+		MaxReviewers:           3,
+		RequiredApprovals:      2,
+		PreferWorkingHours:     true,
+		RequiredSeniority:      models.SenioritySenior,
+		RequiredSeniorityCount: 1,
+	}
+	junior := models.SeniorityJunior
+
+	tests := []struct {
+		name    string
+		update  models.TeamSettingsUpdate
+		want    models.TeamSettings
+		wantErr error
+	}{
+		{
+			// Клиент меняет только границы, остальные настройки сохраняются
+			name:   "partial update keeps other fields",
+			update: models.TeamSettingsUpdate{TeamName: "backend", MinReviewers: intPtr(2), MaxReviewers: intPtr(4)},
+			want: models.TeamSettings{
+				TeamName:               "backend",
+				MinReviewers:           2,
+				MaxReviewers:           4,
+				RequiredApprovals:      2,
+				PreferWorkingHours:     true,
+				RequiredSeniority:      models.SenioritySenior,
+				RequiredSeniorityCount: 1,
+			},
+		},
+		{
+			name:   "empty update keeps settings",
+			update: models.TeamSettingsUpdate{TeamName: "backend"},
+			want:   stored,
+		},
+		{
+			name: "change seniority level",
+			update: models.TeamSettingsUpdate{
+				TeamName:          "backend",
+				RequiredSeniority: &junior,
+			},
+			want: func() models.TeamSettings {
+				settings := stored
+				settings.RequiredSeniority = models.SeniorityJunior
+				return settings
+			}(),
+		},
+		{
+			name:   "zero seniority count drops the level",
+			update: models.TeamSettingsUpdate{TeamName: "backend", RequiredSeniorityCount: intPtr(0)},
+			want: func() models.TeamSettings {
+				settings := stored
+				settings.RequiredSeniority = ""
+				settings.RequiredSeniorityCount = 0
+				return settings
+			}(),
+		},
+		{
+			// С сохраненными required_approvals = 2 меньший max_reviewers недопустим
+			name:    "partial update conflicts with stored fields",
+			update:  models.TeamSettingsUpdate{TeamName: "backend", MaxReviewers: intPtr(1)},
+			wantErr: ErrInvalidSettings,
+		},
+		{
+			name:    "min above max",
+			update:  models.TeamSettingsUpdate{TeamName: "backend", MinReviewers: intPtr(4)},
+			wantErr: ErrInvalidSettings,
+		},
+		{
+			name:    "max above limit",
+			update:  models.TeamSettingsUpdate{TeamName: "backend", MaxReviewers: intPtr(maxReviewersLimit + 1)},
+			wantErr: ErrInvalidSettings,
+		},
+		{
+			name:    "negative min",
+			update:  models.TeamSettingsUpdate{TeamName: "backend", MinReviewers: intPtr(-1)},
+			wantErr: ErrInvalidSettings,
+		},
+		{
+			name:    "unknown team",
+			update:  models.TeamSettingsUpdate{TeamName: "frontend", MaxReviewers: intPtr(2)},
+			wantErr: ErrTeamNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teamRepo := &fakeTeamSettingsRepository{settings: stored}
+			s := NewTeamService(teamRepo, nil, passthroughTransactor{}, nil, nil, nil, nil, SystemClock)
+
+			updated, err := s.UpdateSettings(context.Background(), &tt.update)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateSettings() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if teamRepo.saved {
+					t.Error("invalid settings were saved")
+				}
+				return
+			}
+
+			if *updated != tt.want {
+				t.Errorf("updated = %+v, want %+v", *updated, tt.want)
+			}
+			if !teamRepo.saved || teamRepo.settings != tt.want {
+				t.Errorf("saved = %+v, want %+v", teamRepo.settings, tt.want)
+			}
+		})
+	}
+}
+
+func TestTeamSettingsApplyPolicy(t *testing.T) {
+	three := 3
+	policies, err := NewPolicySet([]*models.TeamPolicy{{Team: "backend", MinReviewers: &three, MaxReviewers: &three}})
+	if err != nil {
+		t.Fatalf("failed to create policies: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		update    models.TeamSettingsUpdate
+		wantErr   error
+		wantSaved bool
+	}{
+		{
+			name:      "consistent with policy",
+			update:    models.TeamSettingsUpdate{TeamName: "backend", RequiredApprovals: intPtr(2)},
+			wantSaved: true,
+		},
+		{
+			// Сами по себе настройки корректны, но политика задает 3 ревьювера,
+			// и 4 одобрения никогда не наберутся
+			name:    "inconsistent with policy",
+			update:  models.TeamSettingsUpdate{TeamName: "backend", MaxReviewers: intPtr(5), RequiredApprovals: intPtr(4)},
+			wantErr: ErrInvalidSettings,
+		},
+		{
+			name:    "invalid without policy",
+			update:  models.TeamSettingsUpdate{TeamName: "backend", MaxReviewers: intPtr(1), RequiredApprovals: intPtr(2)},
+			wantErr: ErrInvalidSettings,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teamRepo := &fakeTeamSettingsRepository{settings: models.TeamSettings{TeamName: "backend", MaxReviewers: 2}}
+			s := NewTeamService(teamRepo, nil, passthroughTransactor{}, nil, nil, policies, nil, SystemClock)
+
+			updated, err := s.UpdateSettings(context.Background(), &tt.update)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateSettings() error = %v, want %v", err, tt.wantErr)
+			}
+			if teamRepo.saved != tt.wantSaved {
+				t.Errorf("settings saved = %v, want %v", teamRepo.saved, tt.wantSaved)
+			}
+			if err != nil {
+				return
+			}
+
+			// В ответе и в GET действующие настройки: число ревьюверов из политики
+			got, err := s.GetSettings(context.Background(), "backend")
+			if err != nil {
+				t.Fatalf("GetSettings() error = %v", err)
+			}
+			for _, settings := range []*models.TeamSettings{updated, got} {
+				if settings.MinReviewers != 3 || settings.MaxReviewers != 3 || settings.RequiredApprovals != *tt.update.RequiredApprovals {
+					t.Errorf("settings = %+v, want min and max reviewers 3 from policy", *settings)
+				}
+			}
+			// Сохраняются настройки без политики
+			if teamRepo.settings.MaxReviewers != 2 {
+				t.Errorf("saved max_reviewers = %d, want 2", teamRepo.settings.MaxReviewers)
+			}
+		})
+	}
+}
+
+// fakeMembershipRepository хранит имена команд, а участников берет
+// из fakeUserRepository
+type fakeMembershipRepository struct {
+	repository.TeamRepository
+	teams map[string]bool
+	users *fakeUserRepository
+	// renameErr возвращается из Rename вместо переименования
+	renameErr error
+}
+
+func (r *fakeMembershipRepository) Exists(_ context.Context, teamName string) (bool, error) {
+	return r.teams[teamName], nil
+}
+
+func (r *fakeMembershipRepository) Get(ctx context.Context, teamName string) (*models.Team, error) {
+	if !r.teams[teamName] {
+		return nil, repository.ErrTeamNotFound
+	}
+	members, _ := r.users.GetByTeam(ctx, teamName)
+	team := &models.Team{TeamName: teamName}
+	for _, user := range members {
+		team.Members = append(team.Members, models.TeamMember{UserID: user.UserID, Username: user.Username, IsActive: user.IsActive})
+	}
+	return team, nil
+}
+
+func (r *fakeMembershipRepository) Rename(_ context.Context, oldName, newName string) error {
+	if r.renameErr != nil {
+		return r.renameErr
+	}
+	if !r.teams[oldName] {
+		return repository.ErrTeamNotFound
+	}
+	if r.teams[newName] {
+		return repository.ErrTeamExists
+	}
+	delete(r.teams, oldName)
+	r.teams[newName] = true
+	for _, user := range r.users.users {
+		if user.TeamName == oldName {
+			user.TeamName = newName
+		}
+	}
+	return nil
+}
+
+// fakeReassignService переназначает ревью, возвращая ошибку для пользователей из fail
+type fakeReassignService struct {
+	PullRequestService
+	fail  map[string]bool
+	calls []string
+}
+
+func (s *fakeReassignService) ReassignOpenReviews(_ context.Context, reviewerIDs []string, _ string) (*models.ReassignmentReport, error) {
+	s.calls = append(s.calls, reviewerIDs...)
+	for _, reviewerID := range reviewerIDs {
+		if s.fail[reviewerID] {
+			return nil, errors.New("lock timeout")
+		}
+	}
+	return &models.ReassignmentReport{}, nil
+}
+
+// membershipFixture - сервис команд над командами backend и frontend
+type membershipFixture struct {
+	service   TeamService
+	teams     *fakeMembershipRepository
+	users     *fakeUserRepository
+	prService *fakeReassignService
+	events    *fakeEventPublisher
+	selectors *SelectorRegistry
+}
+
+func newMembershipFixture(t *testing.T, cfg SelectorConfig, policies *PolicySet) *membershipFixture {
+	t.Helper()
+
+	users := &fakeUserRepository{users: []*models.User{
+		member("alice", "backend"),
+		member("bob", "backend"),
+		member("carol", "frontend"),
+		{UserID: "dave", Username: "dave", IsActive: true},
+	}}
+	teams := &fakeMembershipRepository{teams: map[string]bool{"backend": true, "frontend": true}, users: users}
+	selectors, err := NewSelectorRegistry(cfg, nil)
+	if err != nil {
+		t.Fatalf("failed to create selectors: %v", err)
+	}
+	f := &membershipFixture{
+		teams:     teams,
+		users:     users,
+		prService: &fakeReassignService{},
+		events:    &fakeEventPublisher{},
+		selectors: selectors,
+	}
+	f.service = NewTeamService(teams, users, passthroughTransactor{}, f.prService, selectors, policies, f.events,
+		ClockFunc(func() time.Time { return testNow }))
+	return f
+}
+
+// team возвращает команду пользователя
+func (f *membershipFixture) team(t *testing.T, userID string) string {
+	t.Helper()
+
+	user, err := f.users.Get(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	return user.TeamName
+}
+
+func TestAddMember(t *testing.T) {
+	tests := []struct {
+		name     string
+		team     string
+		member   models.TeamMember
+		wantErr  error
+		wantTeam string
+	}{
+		{
+			name:     "new user",
+			team:     "backend",
+			member:   models.TeamMember{UserID: "erin", Username: "erin", IsActive: true},
+			wantTeam: "backend",
+		},
+		{
+			name:     "user without team",
+			team:     "backend",
+			member:   models.TeamMember{UserID: "dave", Username: "dave", IsActive: true},
+			wantTeam: "backend",
+		},
+		{
+			name:     "member of the same team is updated",
+			team:     "backend",
+			member:   models.TeamMember{UserID: "alice", Username: "Alice", IsActive: true},
+			wantTeam: "backend",
+		},
+		{
+			// Участника другой команды переводят через MoveMember
+			name:     "member of another team",
+			team:     "backend",
+			member:   models.TeamMember{UserID: "carol", Username: "carol", IsActive: true},
+			wantErr:  ErrMemberOfAnotherTeam,
+			wantTeam: "frontend",
+		},
+		{
+			name:    "unknown team",
+			team:    "mobile",
+			member:  models.TeamMember{UserID: "erin", Username: "erin", IsActive: true},
+			wantErr: ErrTeamNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newMembershipFixture(t, SelectorConfig{}, nil)
+
+			team, err := f.service.AddMember(context.Background(), tt.team, tt.member)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddMember() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantTeam != "" {
+				if got := f.team(t, tt.member.UserID); got != tt.wantTeam {
+					t.Errorf("team of %s = %q, want %q", tt.member.UserID, got, tt.wantTeam)
+				}
+			}
+			if err != nil {
+				return
+			}
+
+			found := false
+			for _, member := range team.Members {
+				found = found || member == tt.member
+			}
+			if !found {
+				t.Errorf("members = %+v, want %+v among them", team.Members, tt.member)
+			}
+		})
+	}
+}
+
+func TestAddMemberKeepsReviewLimit(t *testing.T) {
+	f := newMembershipFixture(t, SelectorConfig{}, nil)
+	dave, _ := f.users.Get(context.Background(), "dave")
+	dave.MaxOpenReviews = intPtr(2)
+
+	if _, err := f.service.AddMember(context.Background(), "backend", models.TeamMember{UserID: "dave", Username: "dave", IsActive: true}); err != nil {
+		t.Fatalf("AddMember() error = %v", err)
+	}
+	dave, _ = f.users.Get(context.Background(), "dave")
+	if dave.MaxOpenReviews == nil || *dave.MaxOpenReviews != 2 {
+		t.Errorf("max_open_reviews = %v, want 2", dave.MaxOpenReviews)
+	}
+}
+
+func TestChangeMemberTeam(t *testing.T) {
+	t.Run("move reassigns reviews first", func(t *testing.T) {
+		f := newMembershipFixture(t, SelectorConfig{}, nil)
+
+		if _, err := f.service.MoveMember(context.Background(), "backend", "alice", "frontend"); err != nil {
+			t.Fatalf("MoveMember() error = %v", err)
+		}
+		if got := f.team(t, "alice"); got != "frontend" {
+			t.Errorf("team = %q, want frontend", got)
+		}
+		if want := []string{"alice"}; !reflect.DeepEqual(f.prService.calls, want) {
+			t.Errorf("reassigned reviews of %v, want %v", f.prService.calls, want)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		f := newMembershipFixture(t, SelectorConfig{}, nil)
+
+		if _, err := f.service.RemoveMember(context.Background(), "backend", "alice"); err != nil {
+			t.Fatalf("RemoveMember() error = %v", err)
+		}
+		if got := f.team(t, "alice"); got != "" {
+			t.Errorf("team = %q, want none", got)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		team    string
+		userID  string
+		newTeam string
+		wantErr error
+	}{
+		{name: "not a member", team: "backend", userID: "carol", newTeam: "frontend", wantErr: ErrNotTeamMember},
+		{name: "unknown user", team: "backend", userID: "erin", newTeam: "frontend", wantErr: ErrUserNotFound},
+		{name: "unknown target team", team: "backend", userID: "alice", newTeam: "mobile", wantErr: ErrTeamNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newMembershipFixture(t, SelectorConfig{}, nil)
+
+			if _, err := f.service.MoveMember(context.Background(), tt.team, tt.userID, tt.newTeam); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MoveMember() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(f.prService.calls) != 0 {
+				t.Errorf("reassigned reviews of %v, want none", f.prService.calls)
+			}
+		})
+	}
+
+	t.Run("reassignment failure keeps the team", func(t *testing.T) {
+		f := newMembershipFixture(t, SelectorConfig{}, nil)
+		f.prService.fail = map[string]bool{"alice": true}
+
+		if _, err := f.service.MoveMember(context.Background(), "backend", "alice", "frontend"); err == nil {
+			t.Fatal("MoveMember() error = nil, want reassignment error")
+		}
+		if got := f.team(t, "alice"); got != "backend" {
+			t.Errorf("team = %q, want backend", got)
+		}
+	})
+}
+
+func TestRenameTeam(t *testing.T) {
+	three := 3
+	policies, err := NewPolicySet([]*models.TeamPolicy{{Team: "frontend", MaxReviewers: &three}})
+	if err != nil {
+		t.Fatalf("failed to create policies: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		cfg       SelectorConfig
+		policies  *PolicySet
+		newName   string
+		renameErr error
+		wantErr   error
+	}{
+		{name: "rename", policies: policies, newName: "platform"},
+		{name: "name taken", newName: "frontend", wantErr: ErrTeamExists},
+		{name: "new name has policy", policies: policies, newName: "frontend", wantErr: ErrTeamConfigured},
+		{
+			name:    "team strategy configured",
+			cfg:     SelectorConfig{TeamStrategies: map[string]string{"backend": StrategyLeastLoaded}},
+			newName: "platform",
+			wantErr: ErrTeamConfigured,
+		},
+		{
+			name:    "strict capacity configured for new name",
+			cfg:     SelectorConfig{StrictCapacityTeams: []string{"platform"}},
+			newName: "platform",
+			wantErr: ErrTeamConfigured,
+		},
+		{name: "team not found", newName: "platform", renameErr: repository.ErrTeamNotFound, wantErr: ErrTeamNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newMembershipFixture(t, tt.cfg, tt.policies)
+			f.teams.renameErr = tt.renameErr
+
+			team, err := f.service.RenameTeam(context.Background(), "backend", tt.newName)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RenameTeam() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !f.teams.teams["backend"] {
+					t.Error("team was renamed despite the error")
+				}
+				return
+			}
+			if team.TeamName != tt.newName || len(team.Members) != 2 {
+				t.Errorf("team = %+v, want %s with 2 members", team, tt.newName)
+			}
+		})
+	}
+
+	t.Run("database error is not reported as not found", func(t *testing.T) {
+		f := newMembershipFixture(t, SelectorConfig{}, nil)
+		f.teams.renameErr = errors.New("connection reset")
+
+		_, err := f.service.RenameTeam(context.Background(), "backend", "platform")
+		if err == nil || errors.Is(err, ErrTeamNotFound) {
+			t.Fatalf("RenameTeam() error = %v, want wrapped database error", err)
+		}
+	})
+
+	t.Run("round-robin queue moves to new name", func(t *testing.T) {
+		f := newMembershipFixture(t, SelectorConfig{DefaultStrategy: StrategyRoundRobin}, nil)
+		candidates := []*models.User{member("alice", "backend"), member("bob", "backend"), member("carol", "backend")}
+		pick := func(team string) string {
+			selected, err := f.selectors.ForTeam(team).Select(context.Background(), team, candidates, 1)
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			return selected[0].UserID
+		}
+
+		if got := pick("backend"); got != "alice" {
+			t.Fatalf("first pick = %s, want alice", got)
+		}
+		if _, err := f.service.RenameTeam(context.Background(), "backend", "platform"); err != nil {
+			t.Fatalf("RenameTeam() error = %v", err)
+		}
+		if got := pick("platform"); got != "bob" {
+			t.Errorf("pick after rename = %s, want bob", got)
+		}
+	})
+}
+
+func TestDeactivateMembers(t *testing.T) {
+	t.Run("selected members", func(t *testing.T) {
+		f := newMembershipFixture(t, SelectorConfig{}, nil)
+
+		deactivated, _, err := f.service.DeactivateMembers(context.Background(), "backend", []string{"alice"})
+		if err != nil {
+			t.Fatalf("DeactivateMembers() error = %v", err)
+		}
+		if len(deactivated) != 1 || deactivated[0].UserID != "alice" || deactivated[0].IsActive {
+			t.Errorf("deactivated = %+v, want inactive alice", deactivated)
+		}
+		if bob, _ := f.users.Get(context.Background(), "bob"); !bob.IsActive {
+			t.Error("bob was deactivated")
+		}
+		if want := []string{"alice"}; !reflect.DeepEqual(f.prService.calls, want) {
+			t.Errorf("reassigned reviews of %v, want %v", f.prService.calls, want)
+		}
+		if len(f.events.events) != 1 || f.events.events[0].Type != models.EventUserDeactivated {
+			t.Fatalf("events = %+v, want one %s", f.events.events, models.EventUserDeactivated)
+		}
+		// Время события берется из часов сервиса
+		if occurredAt := f.events.events[0].OccurredAt; !occurredAt.Equal(testNow) {
+			t.Errorf("occurred at %v, want %v", occurredAt, testNow)
+		}
+	})
+
+	t.Run("whole team", func(t *testing.T) {
+		f := newMembershipFixture(t, SelectorConfig{}, nil)
+		// Уже неактивный участник не попадает в ответ, но его ревью тоже переназначаются
+		bob, _ := f.users.Get(context.Background(), "bob")
+		bob.IsActive = false
+
+		deactivated, _, err := f.service.DeactivateMembers(context.Background(), "backend", nil)
+		if err != nil {
+			t.Fatalf("DeactivateMembers() error = %v", err)
+		}
+		if len(deactivated) != 1 || deactivated[0].UserID != "alice" {
+			t.Errorf("deactivated = %+v, want only alice", deactivated)
+		}
+		if want := []string{"alice", "bob"}; !reflect.DeepEqual(f.prService.calls, want) {
+			t.Errorf("reassigned reviews of %v, want %v", f.prService.calls, want)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		team    string
+		userIDs []string
+		wantErr error
+	}{
+		{name: "not a member", team: "backend", userIDs: []string{"alice", "carol"}, wantErr: ErrNotTeamMember},
+		{name: "unknown team", team: "mobile", wantErr: ErrTeamNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newMembershipFixture(t, SelectorConfig{}, nil)
+
+			if _, _, err := f.service.DeactivateMembers(context.Background(), tt.team, tt.userIDs); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeactivateMembers() error = %v, want %v", err, tt.wantErr)
+			}
+			if alice, _ := f.users.Get(context.Background(), "alice"); !alice.IsActive {
+				t.Error("alice was deactivated")
+			}
+		})
+	}
+}
@@ -11,40 +11,103 @@ import (
 // UserService определяет интерфейс для работы с пользователями
 type UserService interface {
 	SetUserActive(ctx context.Context, userID string, isActive bool) (*models.User, error)
+	DeactivateUser(ctx context.Context, userID string) (*models.User, *models.ReassignmentReport, error)
 	GetUserReviews(ctx context.Context, userID string) ([]*models.PullRequestShort, error)
+	GetAbsences(ctx context.Context, userID string) ([]models.AbsencePeriod, error)
+	SetAbsences(ctx context.Context, userID string, periods []models.AbsencePeriod) ([]models.AbsencePeriod, error)
+	SetWorkingHours(ctx context.Context, userID, timezone, workStart, workEnd string) (*models.User, error)
+	SetSkills(ctx context.Context, userID string, skills []string) (*models.User, error)
+	SetSeniority(ctx context.Context, userID string, seniority models.Seniority) (*models.User, error)
 }
 
+// maxAbsencePeriods - сколько текущих и будущих периодов отсутствия можно задать пользователю
+const maxAbsencePeriods = 50
+
 // userService реализует UserService
 type userService struct {
-	userRepo repository.UserRepository
-	prRepo   repository.PullRequestRepository
+	userRepo         repository.UserRepository
+	prRepo           repository.PullRequestRepository
+	availabilityRepo repository.AvailabilityRepository
+	tx               repository.Transactor
+	prService        PullRequestService
+	events           EventPublisher
+	clock            Clock
 }
 
 // NewUserService создает новый сервис для работы с пользователями
 func NewUserService(
 	userRepo repository.UserRepository,
 	prRepo repository.PullRequestRepository,
+	availabilityRepo repository.AvailabilityRepository,
+	tx repository.Transactor,
+	prService PullRequestService,
+	events EventPublisher,
+	clock Clock,
 ) UserService {
 	return &userService{
-		userRepo: userRepo,
-		prRepo:   prRepo,
+		userRepo:         userRepo,
+		prRepo:           prRepo,
+		availabilityRepo: availabilityRepo,
+		tx:               tx,
+		prService:        prService,
+		events:           events,
+		clock:            clock,
 	}
 }
 
 // SetUserActive устанавливает статус активности пользователя
 func (s *userService) SetUserActive(ctx context.Context, userID string, isActive bool) (*models.User, error) {
-	if err := s.userRepo.SetActive(ctx, userID, isActive); err != nil {
-		return nil, ErrUserNotFound
-	}
+	var user *models.User
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		previous, err := s.userRepo.Get(ctx, userID)
+		if err != nil {
+			return ErrUserNotFound
+		}
 
-	user, err := s.userRepo.Get(ctx, userID)
+		if err := s.userRepo.SetActive(ctx, userID, isActive); err != nil {
+			return ErrUserNotFound
+		}
+
+		user, err = s.userRepo.Get(ctx, userID)
+		if err != nil {
+			return ErrUserNotFound
+		}
+
+		if previous.IsActive && !user.IsActive {
+			return emit(ctx, s.events, s.clock, models.EventUserDeactivated, map[string]interface{}{"user": user})
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, ErrUserNotFound
+		return nil, err
 	}
 
 	return user, nil
 }
 
+// DeactivateUser деактивирует пользователя и в той же транзакции переназначает
+// все его открытые ревью по правилам ReassignReviewer. PR, для которых
+// не нашлось замены, остаются за ним и перечисляются в отчете
+func (s *userService) DeactivateUser(ctx context.Context, userID string) (*models.User, *models.ReassignmentReport, error) {
+	var user *models.User
+	var report *models.ReassignmentReport
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.SetUserActive(ctx, userID, false)
+		if err != nil {
+			return err
+		}
+
+		report, err = s.prService.ReassignOpenReviews(ctx, []string{userID}, "reviewer deactivated")
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, report, nil
+}
+
 // GetUserReviews возвращает PR, где пользователь назначен ревьювером
 func (s *userService) GetUserReviews(ctx context.Context, userID string) ([]*models.PullRequestShort, error) {
 	// Проверяем существование пользователя
@@ -60,3 +123,118 @@ func (s *userService) GetUserReviews(ctx context.Context, userID string) ([]*mod
 
 	return prs, nil
 }
+
+// GetAbsences возвращает текущие и будущие периоды отсутствия пользователя
+func (s *userService) GetAbsences(ctx context.Context, userID string) ([]models.AbsencePeriod, error) {
+	if _, err := s.userRepo.Get(ctx, userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	periods, err := s.availabilityRepo.ListUpcoming(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absence periods: %w", err)
+	}
+	return periods, nil
+}
+
+// SetAbsences заменяет текущие и будущие периоды отсутствия пользователя.
+// Открытые ревью переназначаются фоновой задачей, когда период начнется
+func (s *userService) SetAbsences(ctx context.Context, userID string, periods []models.AbsencePeriod) ([]models.AbsencePeriod, error) {
+	if len(periods) > maxAbsencePeriods {
+		return nil, ErrInvalidAbsence
+	}
+	now := s.clock.Now()
+	for i := range periods {
+		if !periods[i].EndsAt.After(periods[i].StartsAt) || !periods[i].EndsAt.After(now) {
+			return nil, ErrInvalidAbsence
+		}
+	}
+
+	var result []models.AbsencePeriod
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepo.Get(ctx, userID); err != nil {
+			return ErrUserNotFound
+		}
+
+		if err := s.availabilityRepo.ReplaceUpcoming(ctx, userID, periods); err != nil {
+			return fmt.Errorf("failed to set absence periods: %w", err)
+		}
+
+		var err error
+		result, err = s.availabilityRepo.ListUpcoming(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get absence periods: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SetWorkingHours задает часовой пояс и рабочие часы пользователя.
+// Пустые значения сбрасывают их
+func (s *userService) SetWorkingHours(ctx context.Context, userID, timezone, workStart, workEnd string) (*models.User, error) {
+	if err := validateWorkingHours(timezone, workStart, workEnd); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.SetWorkingHours(ctx, userID, timezone, workStart, workEnd); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	user, err := s.userRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// SetSkills заменяет навыки пользователя
+func (s *userService) SetSkills(ctx context.Context, userID string, skills []string) (*models.User, error) {
+	normalized, err := normalizeTags(skills)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepo.Get(ctx, userID); err != nil {
+			return ErrUserNotFound
+		}
+		if err := s.userRepo.SetSkills(ctx, userID, normalized); err != nil {
+			return err
+		}
+
+		user, err = s.userRepo.Get(ctx, userID)
+		if err != nil {
+			return ErrUserNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// SetSeniority задает уровень пользователя. Пустое значение сбрасывает его.
+// Уже назначенные ревьюверы не меняются
+func (s *userService) SetSeniority(ctx context.Context, userID string, seniority models.Seniority) (*models.User, error) {
+	if seniority != "" && !validSeniority(seniority) {
+		return nil, ErrInvalidSeniority
+	}
+
+	if err := s.userRepo.SetSeniority(ctx, userID, seniority); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	user, err := s.userRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+func TestDeactivateUserReassignsOpenReviews(t *testing.T) {
+	users := &fakeUserRepository{users: []*models.User{
+		member("author", "backend"),
+		member("alice", "backend"),
+		member("bob", "backend"),
+		member("carol", "backend"),
+	}}
+	teams := &fakeTeamRepository{defaults: models.TeamSettings{MaxReviewers: 2}}
+	merged := openPR("pr-3", "author", "alice")
+	merged.Status = models.StatusMerged
+	prRepo := newFakePullRequestRepository(
+		openPR("pr-1", "author", "alice", "bob"),
+		// Все участники команды, кроме автора, уже назначены
+		openPR("pr-2", "author", "alice", "bob", "carol"),
+		merged,
+	)
+	prService := newAssignmentTestService(t, users, teams, prRepo)
+	events := &fakeEventPublisher{}
+	prService.events = events
+	userService := NewUserService(users, prRepo, nil, passthroughTransactor{}, prService, events,
+		ClockFunc(func() time.Time { return testNow }))
+
+	user, report, err := userService.DeactivateUser(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("DeactivateUser() error = %v", err)
+	}
+	if user.IsActive {
+		t.Error("alice is still active")
+	}
+
+	want := &models.ReassignmentReport{
+		Reassigned:  []models.ReviewReassignment{{PullRequestID: "pr-1", OldReviewerID: "alice", NewReviewerID: "carol"}},
+		NoCandidate: []models.ReviewReassignment{{PullRequestID: "pr-2", OldReviewerID: "alice"}},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("report = %+v, want %+v", report, want)
+	}
+
+	// Без замены ревьювер остается назначенным, слитые PR не меняются
+	wantReviewers := map[string][]string{
+		"pr-1": {"bob", "carol"},
+		"pr-2": {"alice", "bob", "carol"},
+		"pr-3": {"alice"},
+	}
+	for prID, want := range wantReviewers {
+		if got := prRepo.prs[prID].AssignedReviewers; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s reviewers = %v, want %v", prID, got, want)
+		}
+	}
+
+	var types []models.WebhookEventType
+	for _, event := range events.events {
+		types = append(types, event.Type)
+	}
+	if want := []models.WebhookEventType{models.EventUserDeactivated, models.EventReviewerReassigned}; !reflect.DeepEqual(types, want) {
+		t.Errorf("events = %v, want %v", types, want)
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// Итоги обработки события внешней системы
+const (
+	VCSResultProcessed = "processed"
+	VCSResultDuplicate = "duplicate"
+	VCSResultIgnored   = "ignored"
+)
+
+// VCSEventResult описывает итог обработки события PR из внешней системы
+type VCSEventResult struct {
+	Status      string              `json:"status"`
+	PullRequest *models.PullRequest `json:"pr,omitempty"`
+}
+
+// VCSService определяет интерфейс для обработки событий внешних систем контроля версий.
+// Не зависит от конкретного провайдера: вебхуки GitHub/GitLab приводятся
+// к models.VCSPullRequestEvent в обработчиках
+type VCSService interface {
+	HandlePullRequestEvent(ctx context.Context, event *models.VCSPullRequestEvent) (*VCSEventResult, error)
+	LinkIdentity(ctx context.Context, identity *models.VCSIdentity) error
+}
+
+// vcsService реализует VCSService
+type vcsService struct {
+	vcsRepo   repository.VCSRepository
+	userRepo  repository.UserRepository
+	prRepo    repository.PullRequestRepository
+	tx        repository.Transactor
+	prService PullRequestService
+}
+
+// NewVCSService создает новый сервис интеграции с системами контроля версий
+func NewVCSService(
+	vcsRepo repository.VCSRepository,
+	userRepo repository.UserRepository,
+	prRepo repository.PullRequestRepository,
+	tx repository.Transactor,
+	prService PullRequestService,
+) VCSService {
+	return &vcsService{
+		vcsRepo:   vcsRepo,
+		userRepo:  userRepo,
+		prRepo:    prRepo,
+		tx:        tx,
+		prService: prService,
+	}
+}
+
+// HandlePullRequestEvent применяет событие к PR. Доставка помечается обработанной
+// в той же транзакции, что и изменения PR, поэтому при ошибке повтор доставки
+// будет обработан заново, а успешная доставка - только один раз
+func (s *vcsService) HandlePullRequestEvent(ctx context.Context, event *models.VCSPullRequestEvent) (*VCSEventResult, error) {
+	var result *VCSEventResult
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if event.DeliveryID != "" {
+			first, err := s.vcsRepo.MarkDelivery(ctx, event.Provider, event.DeliveryID)
+			if err != nil {
+				return err
+			}
+			if !first {
+				result = &VCSEventResult{Status: VCSResultDuplicate}
+				return nil
+			}
+		}
+
+		actorID, err := s.actorID(ctx, event.Provider, event.SenderLogin)
+		if err != nil {
+			return err
+		}
+		ctx = WithActor(ctx, actorID)
+
+		pr, err := s.apply(ctx, event)
+		if err != nil {
+			return err
+		}
+
+		result = &VCSEventResult{Status: VCSResultProcessed, PullRequest: pr}
+		if pr == nil {
+			result.Status = VCSResultIgnored
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// apply выполняет операцию PullRequestService, соответствующую действию.
+// Возвращает nil без ошибки, если событие не требует изменений
+func (s *vcsService) apply(ctx context.Context, event *models.VCSPullRequestEvent) (*models.PullRequest, error) {
+	var pr *models.PullRequest
+	var err error
+
+	switch event.Action {
+	case models.VCSActionOpened:
+		authorID, resolveErr := s.vcsRepo.ResolveUserID(ctx, event.Provider, event.AuthorLogin)
+		if errors.Is(resolveErr, repository.ErrIdentityNotFound) {
+			return nil, ErrUnknownIdentity
+		}
+		if resolveErr != nil {
+			return nil, fmt.Errorf("failed to resolve author: %w", resolveErr)
+		}
+		// PR уже создан раньше, доставка фиксируется как обработанная.
+		// Проверка идет до вставки: ошибка уникальности прервала бы транзакцию
+		// вместе с отметкой о доставке. Если PR создадут параллельно, доставка
+		// завершится ошибкой, а ее повтор будет пропущен здесь
+		exists, existsErr := s.prRepo.Exists(ctx, event.PullRequestID)
+		if existsErr != nil {
+			return nil, fmt.Errorf("failed to check PR existence: %w", existsErr)
+		}
+		if exists {
+			return nil, nil
+		}
+		pr, err = s.prService.CreatePullRequest(ctx, CreatePullRequestParams{
+			PullRequestID:   event.PullRequestID,
+			PullRequestName: event.PullRequestName,
+			AuthorID:        authorID,
+			Draft:           event.Draft,
+		})
+	case models.VCSActionMerged:
+		// Во внешней системе PR уже слит, поэтому одобрения не проверяются
+		pr, err = s.prService.MergePullRequest(ctx, event.PullRequestID, true)
+	case models.VCSActionClosed:
+		pr, err = s.prService.ClosePullRequest(ctx, event.PullRequestID)
+	case models.VCSActionReopened:
+		pr, err = s.prService.ReopenPullRequest(ctx, event.PullRequestID)
+	case models.VCSActionReady:
+		pr, err = s.prService.MarkReady(ctx, event.PullRequestID)
+	default:
+		return nil, nil
+	}
+
+	// PR, открытые до подключения интеграции, сервису неизвестны
+	if errors.Is(err, ErrPRNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// actorID возвращает пользователя сервиса для логина отправителя события,
+// а если сопоставления нет - логин с префиксом провайдера
+func (s *vcsService) actorID(ctx context.Context, provider models.VCSProvider, login string) (string, error) {
+	if login == "" {
+		return string(provider), nil
+	}
+	userID, err := s.vcsRepo.ResolveUserID(ctx, provider, login)
+	if errors.Is(err, repository.ErrIdentityNotFound) {
+		return fmt.Sprintf("%s:%s", provider, login), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve sender: %w", err)
+	}
+	return userID, nil
+}
+
+// LinkIdentity сопоставляет логин во внешней системе с пользователем сервиса
+func (s *vcsService) LinkIdentity(ctx context.Context, identity *models.VCSIdentity) error {
+	if _, err := s.userRepo.Get(ctx, identity.UserID); err != nil {
+		return ErrUserNotFound
+	}
+
+	if err := s.vcsRepo.UpsertIdentity(ctx, identity); err != nil {
+		return fmt.Errorf("failed to link vcs identity: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/database/dbtest"
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// Повторное событие opened для уже созданного PR не должно прерывать
+// транзакцию обработки доставки
+func TestHandlePullRequestEventOpenedTwice(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	createTestTeam(t, db, "vcs-team", "vcs-author", "vcs-reviewer")
+
+	vcsRepo := repository.NewVCSRepository(db)
+	vcsService := NewVCSService(vcsRepo, repository.NewUserRepository(db), repository.NewPullRequestRepository(db),
+		repository.NewTransactor(db), newTestPullRequestService(t, db))
+	identity := &models.VCSIdentity{Provider: models.ProviderGitHub, Login: "octocat", UserID: "vcs-author"}
+	if err := vcsService.LinkIdentity(ctx, identity); err != nil {
+		t.Fatalf("failed to link identity: %v", err)
+	}
+
+	event := func(deliveryID string) *models.VCSPullRequestEvent {
+		return &models.VCSPullRequestEvent{
+			Provider:        models.ProviderGitHub,
+			DeliveryID:      deliveryID,
+			Action:          models.VCSActionOpened,
+			PullRequestID:   "acme/widgets#42",
+			PullRequestName: "Add search endpoint",
+			AuthorLogin:     "octocat",
+			SenderLogin:     "octocat",
+		}
+	}
+
+	result, err := vcsService.HandlePullRequestEvent(ctx, event("delivery-1"))
+	if err != nil {
+		t.Fatalf("first delivery failed: %v", err)
+	}
+	if result.Status != VCSResultProcessed {
+		t.Fatalf("first delivery status = %s, want %s", result.Status, VCSResultProcessed)
+	}
+
+	result, err = vcsService.HandlePullRequestEvent(ctx, event("delivery-2"))
+	if err != nil {
+		t.Fatalf("second delivery failed: %v", err)
+	}
+	if result.Status != VCSResultIgnored {
+		t.Fatalf("second delivery status = %s, want %s", result.Status, VCSResultIgnored)
+	}
+
+	// Доставка записана: ее повтор распознается как дубликат
+	result, err = vcsService.HandlePullRequestEvent(ctx, event("delivery-2"))
+	if err != nil {
+		t.Fatalf("repeated delivery failed: %v", err)
+	}
+	if result.Status != VCSResultDuplicate {
+		t.Errorf("repeated delivery status = %s, want %s", result.Status, VCSResultDuplicate)
+	}
+}
+
+// fakeVCSRepository сопоставляет логины из identities, а для
+// остальных возвращает resolveErr
+type fakeVCSRepository struct {
+	repository.VCSRepository
+	identities map[string]string
+	resolveErr error
+}
+
+func (r *fakeVCSRepository) ResolveUserID(_ context.Context, _ models.VCSProvider, login string) (string, error) {
+	if userID, ok := r.identities[login]; ok {
+		return userID, nil
+	}
+	return "", r.resolveErr
+}
+
+func (r *fakeVCSRepository) MarkDelivery(context.Context, models.VCSProvider, string) (bool, error) {
+	return true, nil
+}
+
+func TestHandlePullRequestEventResolveErrors(t *testing.T) {
+	dbErr := errors.New("connection reset")
+	tests := []struct {
+		name       string
+		identities map[string]string
+		resolveErr error
+		wantErr    error
+	}{
+		{
+			name:       "unknown author",
+			identities: map[string]string{"sender": "alice"},
+			resolveErr: repository.ErrIdentityNotFound,
+			wantErr:    ErrUnknownIdentity,
+		},
+		{
+			// Сбой БД не выдается за неизвестный логин, иначе ответ 422
+			// остановит повторы доставки
+			name:       "author lookup failure",
+			identities: map[string]string{"sender": "alice"},
+			resolveErr: dbErr,
+			wantErr:    dbErr,
+		},
+		{
+			name:       "sender lookup failure",
+			resolveErr: dbErr,
+			wantErr:    dbErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcsRepo := &fakeVCSRepository{identities: tt.identities, resolveErr: tt.resolveErr}
+			vcsService := NewVCSService(vcsRepo, nil, nil, passthroughTransactor{}, nil)
+
+			_, err := vcsService.HandlePullRequestEvent(context.Background(), &models.VCSPullRequestEvent{
+				Provider:      models.ProviderGitHub,
+				DeliveryID:    "delivery-1",
+				Action:        models.VCSActionOpened,
+				PullRequestID: "acme/widgets#42",
+				AuthorLogin:   "author",
+				SenderLogin:   "sender",
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("HandlePullRequestEvent() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// Заголовки исходящих вебхуков
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookID        = "X-Webhook-Id"
+	HeaderWebhookAttempt   = "X-Webhook-Attempt"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// WebhookDispatcherConfig описывает параметры доставки исходящих вебхуков
+type WebhookDispatcherConfig struct {
+	// MaxAttempts - число попыток доставки одного события подписчику
+	MaxAttempts int
+	// InitialBackoff - пауза перед второй попыткой, далее удваивается
+	InitialBackoff time.Duration
+	// MaxBackoff ограничивает паузу между попытками
+	MaxBackoff time.Duration
+	// Timeout - таймаут одного HTTP запроса
+	Timeout time.Duration
+	// Workers - число параллельных доставок
+	Workers int
+	// PollInterval - пауза между проверками, когда заданий для доставки нет
+	PollInterval time.Duration
+}
+
+// WebhookDispatcher реализует EventSink: превращает событие в задания доставки
+// подписчикам и выполняет их в фоне с повторами и экспоненциальной паузой,
+// записывая каждую попытку в журнал
+type WebhookDispatcher struct {
+	webhookRepo repository.WebhookRepository
+	tx          repository.Transactor
+	client      *http.Client
+	cfg         WebhookDispatcherConfig
+}
+
+// NewWebhookDispatcher создает диспетчер исходящих вебхуков.
+// Доставка начинается после вызова Run
+func NewWebhookDispatcher(webhookRepo repository.WebhookRepository, tx repository.Transactor, cfg WebhookDispatcherConfig) *WebhookDispatcher {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+
+	return &WebhookDispatcher{
+		webhookRepo: webhookRepo,
+		tx:          tx,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		cfg:         cfg,
+	}
+}
+
+// Name реализует EventSink
+func (d *WebhookDispatcher) Name() string {
+	return "webhooks"
+}
+
+// Send создает задания доставки события всем подходящим подпискам.
+// Задания пишутся в транзакции публикации из outbox: событие отмечается
+// опубликованным только вместе с ними, а при откате не создается ни одного
+func (d *WebhookDispatcher) Send(ctx context.Context, event *models.WebhookEvent) error {
+	subs, err := d.webhookRepo.ListSubscriptionsForEvent(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	jobs := make([]*models.WebhookJob, 0, len(subs))
+	for _, sub := range subs {
+		jobs = append(jobs, &models.WebhookJob{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Body:           body,
+		})
+	}
+	return d.webhookRepo.AddJobs(ctx, jobs)
+}
+
+// Run доставляет задания, пока не будет отменен ctx
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < d.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// work доставляет задания по одному, а когда их нет, ждет PollInterval
+func (d *WebhookDispatcher) work(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			found, err := d.deliverNext(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Failed to deliver webhook: %v", err)
+			}
+			if err != nil || !found {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverNext выполняет одну попытку доставки ближайшего задания и сообщает,
+// нашлось ли оно. Задание заблокировано до конца попытки: если сервис
+// остановится раньше, транзакция откатится и задание выполнится после перезапуска
+func (d *WebhookDispatcher) deliverNext(ctx context.Context) (bool, error) {
+	found := false
+	err := d.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		jobs, err := d.webhookRepo.ClaimDueJobs(ctx, 1)
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+		found = true
+
+		job := jobs[0]
+		attempt := job.Attempts + 1
+		delivery := d.send(ctx, job, attempt)
+		if ctx.Err() != nil {
+			// Попытка прервана остановкой сервиса и не засчитывается
+			return ctx.Err()
+		}
+		if err := d.webhookRepo.AddDelivery(ctx, delivery); err != nil {
+			return err
+		}
+
+		switch {
+		case delivery.Succeeded:
+			return d.webhookRepo.CompleteJob(ctx, job.ID, "")
+		case attempt >= d.cfg.MaxAttempts:
+			log.Printf("Giving up on webhook %s to subscription %d after %d attempts: %s", job.EventID, job.SubscriptionID, attempt, delivery.Error)
+			return d.webhookRepo.CompleteJob(ctx, job.ID, delivery.Error)
+		default:
+			return d.webhookRepo.RescheduleJob(ctx, job.ID, d.backoff(attempt), delivery.Error)
+		}
+	})
+	return found, err
+}
+
+// backoff возвращает паузу после неудачной попытки attempt: InitialBackoff,
+// удваиваемый с каждой следующей попыткой и ограниченный MaxBackoff
+func (d *WebhookDispatcher) backoff(attempt int) time.Duration {
+	backoff := d.cfg.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if d.cfg.MaxBackoff > 0 && backoff > d.cfg.MaxBackoff {
+			break
+		}
+	}
+	if d.cfg.MaxBackoff > 0 && backoff > d.cfg.MaxBackoff {
+		return d.cfg.MaxBackoff
+	}
+	return backoff
+}
+
+// send выполняет одну попытку доставки. Успешной считается попытка с ответом 2xx
+func (d *WebhookDispatcher) send(ctx context.Context, job *models.WebhookJob, attempt int) *models.WebhookDelivery {
+	delivery := &models.WebhookDelivery{
+		SubscriptionID: job.SubscriptionID,
+		EventID:        job.EventID,
+		EventType:      job.EventType,
+		Attempt:        attempt,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(job.Body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookEvent, string(job.EventType))
+	req.Header.Set(HeaderWebhookID, job.EventID)
+	req.Header.Set(HeaderWebhookAttempt, strconv.Itoa(attempt))
+	req.Header.Set(HeaderWebhookSignature, SignWebhookBody(job.Secret, job.Body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	_ = resp.Body.Close()
+
+	delivery.StatusCode = &resp.StatusCode
+	delivery.Succeeded = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Succeeded {
+		delivery.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return delivery
+}
+
+// SignWebhookBody возвращает подпись тела в формате "sha256=<hex>" (HMAC-SHA256 с секретом подписки)
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
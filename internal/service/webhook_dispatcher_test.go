@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// fakeWebhookJob - задание доставки в памяти
+type fakeWebhookJob struct {
+	job       models.WebhookJob
+	completed bool
+	reason    string
+	// delays - паузы, на которые откладывались повторы
+	delays []time.Duration
+}
+
+// fakeWebhookRepository хранит подписки, задания и журнал доставки в памяти.
+// Отложенные задания сразу считаются готовыми к следующей попытке
+type fakeWebhookRepository struct {
+	repository.WebhookRepository
+
+	mu         sync.Mutex
+	subs       []*models.WebhookSubscription
+	jobs       []*fakeWebhookJob
+	deliveries []*models.WebhookDelivery
+}
+
+func (r *fakeWebhookRepository) ListSubscriptionsForEvent(context.Context, models.WebhookEventType) ([]*models.WebhookSubscription, error) {
+	return r.subs, nil
+}
+
+func (r *fakeWebhookRepository) AddJobs(_ context.Context, jobs []*models.WebhookJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, job := range jobs {
+		job.ID = int64(len(r.jobs) + 1)
+		r.jobs = append(r.jobs, &fakeWebhookJob{job: *job})
+	}
+	return nil
+}
+
+func (r *fakeWebhookRepository) ClaimDueJobs(_ context.Context, limit int) ([]*models.WebhookJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := []*models.WebhookJob{}
+	for _, stored := range r.jobs {
+		if stored.completed || len(jobs) == limit {
+			continue
+		}
+		job := stored.job
+		for _, sub := range r.subs {
+			if sub.ID == job.SubscriptionID {
+				job.URL = sub.URL
+				job.Secret = sub.Secret
+			}
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+func (r *fakeWebhookRepository) CompleteJob(_ context.Context, id int64, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job := r.jobs[id-1]
+	job.job.Attempts++
+	job.completed = true
+	job.reason = reason
+	return nil
+}
+
+func (r *fakeWebhookRepository) RescheduleJob(_ context.Context, id int64, delay time.Duration, _ string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job := r.jobs[id-1]
+	job.job.Attempts++
+	job.delays = append(job.delays, delay)
+	return nil
+}
+
+func (r *fakeWebhookRepository) AddDelivery(_ context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+// receivedWebhook - запрос, полученный тестовым подписчиком
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver - тестовый подписчик, отвечающий статусами из statuses по очереди.
+// Когда статусы заканчиваются, отвечает последним
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	received []receivedWebhook
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	status := rcv.statuses[len(rcv.statuses)-1]
+	if len(rcv.received) < len(rcv.statuses) {
+		status = rcv.statuses[len(rcv.received)]
+	}
+	rcv.received = append(rcv.received, receivedWebhook{header: r.Header.Clone(), body: body})
+	w.WriteHeader(status)
+}
+
+// newTestDispatcher создает диспетчер с подпиской на server и публикует в него событие
+func newTestDispatcher(t *testing.T, server *httptest.Server, cfg WebhookDispatcherConfig) (*WebhookDispatcher, *fakeWebhookRepository, *models.WebhookEvent) {
+	t.Helper()
+
+	repo := &fakeWebhookRepository{
+		subs: []*models.WebhookSubscription{{ID: 7, URL: server.URL, Secret: "s3cret", IsActive: true}},
+	}
+	dispatcher := NewWebhookDispatcher(repo, passthroughTransactor{}, cfg)
+
+	event := &models.WebhookEvent{
+		ID:         "evt-1",
+		Type:       models.EventPRCreated,
+		OccurredAt: time.Date(2024, 5, 14, 10, 0, 0, 0, time.UTC),
+		Data:       map[string]string{"pull_request_id": "pr-1"},
+	}
+	if err := dispatcher.Send(context.Background(), event); err != nil {
+		t.Fatalf("failed to send event: %v", err)
+	}
+	return dispatcher, repo, event
+}
+
+// deliverAll выполняет попытки доставки, пока есть незавершенные задания
+func deliverAll(t *testing.T, dispatcher *WebhookDispatcher) {
+	t.Helper()
+
+	for i := 0; ; i++ {
+		found, err := dispatcher.deliverNext(context.Background())
+		if err != nil {
+			t.Fatalf("delivery failed: %v", err)
+		}
+		if !found {
+			return
+		}
+		if i > 100 {
+			t.Fatal("delivery does not finish")
+		}
+	}
+}
+
+func TestWebhookDispatcherSignsRequest(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{http.StatusNoContent}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	dispatcher, repo, event := newTestDispatcher(t, server, WebhookDispatcherConfig{MaxAttempts: 3})
+	deliverAll(t, dispatcher)
+
+	if len(receiver.received) != 1 {
+		t.Fatalf("subscriber received %d requests, want 1", len(receiver.received))
+	}
+	request := receiver.received[0]
+
+	wantBody, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("failed to encode event: %v", err)
+	}
+	if string(request.body) != string(wantBody) {
+		t.Errorf("body = %s, want %s", request.body, wantBody)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(request.body)
+	wantSignature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	wantHeaders := map[string]string{
+		"Content-Type":         "application/json",
+		HeaderWebhookEvent:     string(models.EventPRCreated),
+		HeaderWebhookID:        "evt-1",
+		HeaderWebhookAttempt:   "1",
+		HeaderWebhookSignature: wantSignature,
+	}
+	for name, want := range wantHeaders {
+		if got := request.header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	if len(repo.deliveries) != 1 {
+		t.Fatalf("delivery log has %d entries, want 1", len(repo.deliveries))
+	}
+	delivery := repo.deliveries[0]
+	if !delivery.Succeeded || delivery.StatusCode == nil || *delivery.StatusCode != http.StatusNoContent ||
+		delivery.SubscriptionID != 7 || delivery.EventID != "evt-1" || delivery.Attempt != 1 {
+		t.Errorf("unexpected delivery: %+v", delivery)
+	}
+	if job := repo.jobs[0]; !job.completed || job.reason != "" {
+		t.Errorf("job completed = %v with reason %q, want completed without reason", job.completed, job.reason)
+	}
+}
+
+func TestWebhookDispatcherRetries(t *testing.T) {
+	cfg := WebhookDispatcherConfig{
+		MaxAttempts:    5,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name          string
+		statuses      []int
+		wantAttempts  int
+		wantSucceeded bool
+		wantDelays    []time.Duration
+	}{
+		{
+			name:          "succeeds after failures",
+			statuses:      []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+			wantAttempts:  3,
+			wantSucceeded: true,
+			wantDelays:    []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+		},
+		{
+			name:         "gives up after max attempts",
+			statuses:     []int{http.StatusInternalServerError},
+			wantAttempts: 5,
+			wantDelays: []time.Duration{
+				10 * time.Millisecond,
+				20 * time.Millisecond,
+				40 * time.Millisecond,
+				50 * time.Millisecond,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &webhookReceiver{statuses: tt.statuses}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			dispatcher, repo, _ := newTestDispatcher(t, server, cfg)
+			deliverAll(t, dispatcher)
+
+			if len(receiver.received) != tt.wantAttempts {
+				t.Fatalf("subscriber received %d requests, want %d", len(receiver.received), tt.wantAttempts)
+			}
+			for i, request := range receiver.received {
+				if got := request.header.Get(HeaderWebhookAttempt); got != strconv.Itoa(i+1) {
+					t.Errorf("request %d: %s = %q, want %d", i, HeaderWebhookAttempt, got, i+1)
+				}
+			}
+
+			// Каждая попытка записана в журнал
+			if len(repo.deliveries) != tt.wantAttempts {
+				t.Fatalf("delivery log has %d entries, want %d", len(repo.deliveries), tt.wantAttempts)
+			}
+			for i, delivery := range repo.deliveries {
+				last := i == tt.wantAttempts-1
+				wantSucceeded := last && tt.wantSucceeded
+				if delivery.Attempt != i+1 || delivery.Succeeded != wantSucceeded {
+					t.Errorf("delivery %d: attempt %d succeeded %v, want attempt %d succeeded %v",
+						i, delivery.Attempt, delivery.Succeeded, i+1, wantSucceeded)
+				}
+				if !delivery.Succeeded && delivery.Error == "" {
+					t.Errorf("delivery %d: failed attempt has no error", i)
+				}
+			}
+
+			job := repo.jobs[0]
+			if !reflect.DeepEqual(job.delays, tt.wantDelays) {
+				t.Errorf("delays = %v, want %v", job.delays, tt.wantDelays)
+			}
+			if !job.completed || job.job.Attempts != tt.wantAttempts {
+				t.Errorf("job completed = %v after %d attempts, want completed after %d", job.completed, job.job.Attempts, tt.wantAttempts)
+			}
+			if gaveUp := job.reason != ""; gaveUp == tt.wantSucceeded {
+				t.Errorf("job reason = %q, succeeded = %v", job.reason, tt.wantSucceeded)
+			}
+		})
+	}
+}
+
+func TestWebhookDispatcherSkipsUnsubscribedEvents(t *testing.T) {
+	repo := &fakeWebhookRepository{}
+	dispatcher := NewWebhookDispatcher(repo, passthroughTransactor{}, WebhookDispatcherConfig{})
+
+	event := &models.WebhookEvent{ID: "evt-1", Type: models.EventPRMerged}
+	if err := dispatcher.Send(context.Background(), event); err != nil {
+		t.Fatalf("failed to send event: %v", err)
+	}
+	if len(repo.jobs) != 0 {
+		t.Errorf("created %d jobs without subscriptions, want 0", len(repo.jobs))
+	}
+}
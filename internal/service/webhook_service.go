@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+	"github.com/zazaza5818/pr-reviewer-service/internal/repository"
+)
+
+// defaultDeliveryLogLimit - сколько последних попыток доставки возвращает журнал
+const defaultDeliveryLogLimit = 100
+
+// knownEventTypes - события, на которые можно подписаться
+var knownEventTypes = map[models.WebhookEventType]bool{
+	models.EventPRCreated:          true,
+	models.EventReviewerAssigned:   true,
+	models.EventReviewerReassigned: true,
+	models.EventPRMerged:           true,
+	models.EventPRClosed:           true,
+	models.EventUserDeactivated:    true,
+}
+
+// WebhookService определяет интерфейс для управления подписками на исходящие вебхуки
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	GetDeliveries(ctx context.Context, subscriptionID int64) ([]*models.WebhookDelivery, error)
+}
+
+// webhookService реализует WebhookService
+type webhookService struct {
+	webhookRepo repository.WebhookRepository
+}
+
+// NewWebhookService создает новый сервис подписок на исходящие вебхуки
+func NewWebhookService(webhookRepo repository.WebhookRepository) WebhookService {
+	return &webhookService{webhookRepo: webhookRepo}
+}
+
+// CreateSubscription проверяет и создает подписку
+func (s *webhookService) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	target, err := url.Parse(sub.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, ErrInvalidSubscription
+	}
+
+	if sub.Secret == "" {
+		return nil, ErrInvalidSubscription
+	}
+
+	seen := make(map[models.WebhookEventType]bool, len(sub.EventTypes))
+	for _, eventType := range sub.EventTypes {
+		if !knownEventTypes[eventType] || seen[eventType] {
+			return nil, ErrInvalidSubscription
+		}
+		seen[eventType] = true
+	}
+
+	sub.IsActive = true
+	if err := s.webhookRepo.CreateSubscription(ctx, sub); err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+// ListSubscriptions возвращает все подписки
+func (s *webhookService) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	subs, err := s.webhookRepo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// DeleteSubscription удаляет подписку
+func (s *webhookService) DeleteSubscription(ctx context.Context, id int64) error {
+	if err := s.webhookRepo.DeleteSubscription(ctx, id); err != nil {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// GetDeliveries возвращает журнал последних попыток доставки подписки
+func (s *webhookService) GetDeliveries(ctx context.Context, subscriptionID int64) ([]*models.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	deliveries, err := s.webhookRepo.GetDeliveries(ctx, subscriptionID, defaultDeliveryLogLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deliveries: %w", err)
+	}
+	return deliveries, nil
+}
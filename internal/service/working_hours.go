@@ -0,0 +1,86 @@
+package service
+
+import (
+	"time"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+// workingHoursLayout - формат начала и конца рабочего дня
+const workingHoursLayout = "15:04"
+
+// validateWorkingHours проверяет часовой пояс и границы рабочего дня.
+// Часы задаются вместе, часовой пояс без часов допустим
+func validateWorkingHours(timezone, start, end string) error {
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return ErrInvalidWorkingHours
+		}
+	}
+	if (start == "") != (end == "") {
+		return ErrInvalidWorkingHours
+	}
+	if start == "" {
+		return nil
+	}
+	if timezone == "" {
+		return ErrInvalidWorkingHours
+	}
+	startAt, err := time.Parse(workingHoursLayout, start)
+	if err != nil {
+		return ErrInvalidWorkingHours
+	}
+	endAt, err := time.Parse(workingHoursLayout, end)
+	if err != nil {
+		return ErrInvalidWorkingHours
+	}
+	if startAt.Equal(endAt) {
+		return ErrInvalidWorkingHours
+	}
+	return nil
+}
+
+// inWorkingHours сообщает, идет ли у пользователя рабочее время в момент now.
+// Пользователь без заданных часов считается доступным всегда. Если конец
+// раньше начала, рабочий день переходит через полночь
+func inWorkingHours(user *models.User, now time.Time) bool {
+	if user.Timezone == "" || user.WorkStart == "" || user.WorkEnd == "" {
+		return true
+	}
+
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return true
+	}
+	start, err := time.Parse(workingHoursLayout, user.WorkStart)
+	if err != nil {
+		return true
+	}
+	end, err := time.Parse(workingHoursLayout, user.WorkEnd)
+	if err != nil {
+		return true
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
+}
+
+// preferWorking разделяет кандидатов на тех, у кого сейчас рабочее время,
+// и остальных, сохраняя порядок внутри групп
+func preferWorking(candidates []*models.User, now time.Time) (working, other []*models.User) {
+	for _, candidate := range candidates {
+		if inWorkingHours(candidate, now) {
+			working = append(working, candidate)
+		} else {
+			other = append(other, candidate)
+		}
+	}
+	return working, other
+}
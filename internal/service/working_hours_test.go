@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+	// Тесты не зависят от tzdata в системе
+	_ "time/tzdata"
+
+	"github.com/zazaza5818/pr-reviewer-service/internal/models"
+)
+
+func TestInWorkingHours(t *testing.T) {
+	// 2024-05-14 - вторник, летнее время в Европе и США
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 5, 14, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		timezone string
+		start    string
+		end      string
+		now      time.Time
+		want     bool
+	}{
+		{name: "no working hours", now: at(3, 0), want: true},
+		{name: "timezone without hours", timezone: "Europe/Moscow", now: at(3, 0), want: true},
+		{name: "utc inside", timezone: "UTC", start: "09:00", end: "18:00", now: at(12, 0), want: true},
+		{name: "utc start is inclusive", timezone: "UTC", start: "09:00", end: "18:00", now: at(9, 0), want: true},
+		{name: "utc end is exclusive", timezone: "UTC", start: "09:00", end: "18:00", now: at(18, 0), want: false},
+		{name: "utc before start", timezone: "UTC", start: "09:00", end: "18:00", now: at(8, 59), want: false},
+		// 07:00 UTC = 10:00 MSK
+		{name: "moscow morning", timezone: "Europe/Moscow", start: "09:00", end: "18:00", now: at(7, 0), want: true},
+		// 15:30 UTC = 18:30 MSK
+		{name: "moscow evening", timezone: "Europe/Moscow", start: "09:00", end: "18:00", now: at(15, 30), want: false},
+		// 07:00 UTC = 03:00 EDT
+		{name: "new york night", timezone: "America/New_York", start: "09:00", end: "17:00", now: at(7, 0), want: false},
+		// 14:00 UTC = 10:00 EDT
+		{name: "new york day", timezone: "America/New_York", start: "09:00", end: "17:00", now: at(14, 0), want: true},
+		// 14:00 UTC = 23:00 JST
+		{name: "tokyo late evening", timezone: "Asia/Tokyo", start: "09:00", end: "18:00", now: at(14, 0), want: false},
+		// 04:00 UTC = 09:30 IST, смещение с половиной часа
+		{name: "kolkata half hour offset", timezone: "Asia/Kolkata", start: "09:30", end: "18:30", now: at(4, 0), want: true},
+		{name: "kolkata before start", timezone: "Asia/Kolkata", start: "09:30", end: "18:30", now: at(3, 59), want: false},
+		// Ночная смена 22:00-06:00 в Москве
+		{name: "overnight before midnight", timezone: "Europe/Moscow", start: "22:00", end: "06:00", now: at(20, 0), want: true},
+		{name: "overnight after midnight", timezone: "Europe/Moscow", start: "22:00", end: "06:00", now: at(0, 30), want: true},
+		{name: "overnight end is exclusive", timezone: "Europe/Moscow", start: "22:00", end: "06:00", now: at(3, 0), want: false},
+		{name: "overnight daytime", timezone: "Europe/Moscow", start: "22:00", end: "06:00", now: at(9, 0), want: false},
+		// Ночная смена в UTC-7: 03:00 UTC = 20:00 PDT предыдущего дня
+		{name: "overnight across date line", timezone: "America/Los_Angeles", start: "18:00", end: "02:00", now: at(3, 0), want: true},
+		{name: "invalid timezone", timezone: "Mars/Olympus", start: "09:00", end: "18:00", now: at(3, 0), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{UserID: "u1", Timezone: tt.timezone, WorkStart: tt.start, WorkEnd: tt.end}
+			if got := inWorkingHours(user, tt.now); got != tt.want {
+				t.Errorf("inWorkingHours() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickReviewersPrefersWorkingHours(t *testing.T) {
+	// testNow - 07:00 UTC: в Москве и Токио рабочий день, в Нью-Йорке и Лондоне еще нет
+	members := []*models.User{
+		{UserID: "alice", Username: "alice", TeamName: "backend", IsActive: true, Timezone: "Europe/Moscow", WorkStart: "09:00", WorkEnd: "18:00"},
+		{UserID: "bob", Username: "bob", TeamName: "backend", IsActive: true, Timezone: "America/New_York", WorkStart: "09:00", WorkEnd: "17:00"},
+		{UserID: "carol", Username: "carol", TeamName: "backend", IsActive: true, Timezone: "Asia/Tokyo", WorkStart: "09:00", WorkEnd: "18:00"},
+		{UserID: "erin", Username: "erin", TeamName: "backend", IsActive: true, Timezone: "Europe/London", WorkStart: "09:00", WorkEnd: "17:00"},
+	}
+
+	working := map[string]bool{"alice": true, "carol": true}
+
+	tests := []struct {
+		name   string
+		prefer bool
+		count  int
+		want   []string
+		anyOf  map[string]bool
+	}{
+		{name: "working reviewers first", prefer: true, count: 2, want: []string{"alice", "carol"}},
+		{name: "others fill the rest", prefer: true, count: 3, want: []string{"alice", "carol"}, anyOf: map[string]bool{"bob": true, "erin": true}},
+		{name: "preference disabled", prefer: false, count: 4, want: []string{"alice", "bob", "carol", "erin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newReviewerTestService(&fakeUserRepository{users: members}, &fakeTeamRepository{defaults: models.TeamSettings{
+				MaxReviewers:       tt.count,
+				PreferWorkingHours: tt.prefer,
+			}}, nil)
+
+			// Выбор случайный, поэтому повторяем его несколько раз
+			for i := 0; i < repeatRandom; i++ {
+				picked, _, err := s.pickReviewers(context.Background(), reviewerRequest{
+					lookup:     s.newLookup(),
+					policyTeam: "backend",
+					authorID:   "author",
+					teams:      []string{"backend"},
+					exclude:    map[string]bool{},
+					count:      tt.count,
+				})
+				if err != nil {
+					t.Fatalf("pickReviewers() error = %v", err)
+				}
+				if len(picked) != tt.count {
+					t.Fatalf("picked %d reviewers, want %d", len(picked), tt.count)
+				}
+
+				var got []string
+				extra := 0
+				for _, reviewer := range picked {
+					hasReason := false
+					for _, reason := range reviewer.reasons {
+						hasReason = hasReason || reason == "within working hours"
+					}
+					if wantReason := tt.prefer && working[reviewer.user.UserID]; hasReason != wantReason {
+						t.Errorf("%s reasons = %v, want working hours reason %v", reviewer.user.UserID, reviewer.reasons, wantReason)
+					}
+
+					if tt.anyOf[reviewer.user.UserID] {
+						extra++
+						continue
+					}
+					got = append(got, reviewer.user.UserID)
+				}
+				sort.Strings(got)
+				if len(got) != len(tt.want) || extra != tt.count-len(tt.want) {
+					t.Fatalf("picked %v, want %v and %d of %v", got, tt.want, tt.count-len(tt.want), tt.anyOf)
+				}
+				for j := range got {
+					if got[j] != tt.want[j] {
+						t.Fatalf("picked %v, want %v", got, tt.want)
+					}
+				}
+			}
+		})
+	}
+}
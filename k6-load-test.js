@@ -22,16 +22,38 @@ const endpointMetrics = {
   prCreate: { latency: new Trend('pr_create_latency'), errors: new Counter('pr_create_errors') },
   prMerge: { latency: new Trend('pr_merge_latency'), errors: new Counter('pr_merge_errors') },
   prReassign: { latency: new Trend('pr_reassign_latency'), errors: new Counter('pr_reassign_errors') },
+  teamDeactivate: { latency: new Trend('team_deactivate_latency'), errors: new Counter('team_deactivate_errors') },
 };
 
+// Размер команды и число открытых PR в сценарии массовой деактивации
+const DEACTIVATE_TEAM_SIZE = 20;
+const DEACTIVATE_OPEN_PRS = 10;
+const DEACTIVATE_MEMBERS = 5;
+
 // === НАСТРОЙКИ ТЕСТА ===
 export const options = {
-  stages: [
-    { duration: '5s', target: 100 },
-    { duration: '1m', target: 100 },
-    { duration: '5s', target: 100 }
-  ],
+  scenarios: {
+    main: {
+      executor: 'ramping-vus',
+      exec: 'default',
+      stages: [
+        { duration: '5s', target: 100 },
+        { duration: '1m', target: 100 },
+        { duration: '5s', target: 100 }
+      ],
+    },
+    // Массовая деактивация команды из ~20 человек с открытыми ревью
+    deactivate: {
+      executor: 'constant-vus',
+      exec: 'deactivateMembers',
+      vus: 5,
+      duration: '1m',
+      startTime: '5s',
+    },
+  },
   thresholds: {
+    // Бюджет на деактивацию команды из ~20 человек
+    'team_deactivate_latency': ['p(95)<100'],
     // SLI: Availability >= 99.9%
     'sli_availability': ['rate>0.999'],
     // SLI: P95 Latency < 300ms
@@ -163,3 +185,42 @@ export default function () {
   makeRequest('POST', `${BASE_URL}/users/setIsActive`, activePayload, ADMIN_TOKEN, endpointMetrics.userSetActive);
   sleep(0.2);
 }
+
+// === СЦЕНАРИЙ МАССОВОЙ ДЕАКТИВАЦИИ ===
+// Создает команду из DEACTIVATE_TEAM_SIZE человек с открытыми PR и деактивирует
+// часть участников. Замеряется только запрос /team/deactivateMembers
+export function deactivateMembers() {
+  const uniqueId = `${__VU}-${__ITER}-${Date.now()}`;
+  const teamName = `deactivate-team-${uniqueId}`;
+
+  const members = [];
+  for (let i = 1; i <= DEACTIVATE_TEAM_SIZE; i++) {
+    members.push({ user_id: `dt-${uniqueId}-${i}`, username: `DeactivateUser${i}-${__VU}`, is_active: true });
+  }
+  const teamRes = http.post(`${BASE_URL}/team/add`, JSON.stringify({ team_name: teamName, members }), {
+    headers: { 'Content-Type': 'application/json', 'Authorization': `Bearer ${ADMIN_TOKEN}` },
+  });
+  if (teamRes.status !== 201) {
+    return;
+  }
+
+  // Авторы - участники, которые останутся активными
+  for (let i = 1; i <= DEACTIVATE_OPEN_PRS; i++) {
+    const author = members[DEACTIVATE_MEMBERS + (i % (DEACTIVATE_TEAM_SIZE - DEACTIVATE_MEMBERS))];
+    http.post(`${BASE_URL}/pullRequest/create`, JSON.stringify({
+      pull_request_id: `pr-deactivate-${uniqueId}-${i}`,
+      pull_request_name: `Deactivate Load Test PR ${i}`,
+      author_id: author.user_id,
+    }), {
+      headers: { 'Content-Type': 'application/json', 'Authorization': `Bearer ${ADMIN_TOKEN}` },
+    });
+  }
+
+  const deactivatePayload = JSON.stringify({
+    team_name: teamName,
+    user_ids: members.slice(0, DEACTIVATE_MEMBERS).map((member) => member.user_id),
+  });
+  const { res } = makeRequest('POST', `${BASE_URL}/team/deactivateMembers`, deactivatePayload, ADMIN_TOKEN, endpointMetrics.teamDeactivate);
+  check(res, { 'members deactivated': (r) => r.status === 200 });
+  sleep(0.5);
+}
@@ -0,0 +1,2 @@
+-- Индекс для подсчета открытых ревью по участникам команды
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_reviewer_pr ON pr_reviewers(reviewer_id, pull_request_id);
@@ -0,0 +1,12 @@
+-- Черновики и закрытые PR не представимы в старой схеме. Удалять их молча
+-- нельзя, поэтому откат прерывается, пока такие PR есть
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pull_requests WHERE status IN ('DRAFT', 'CLOSED')) THEN
+        RAISE EXCEPTION 'cannot roll back: pull requests in DRAFT or CLOSED status exist';
+    END IF;
+END $$;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS closed_at;
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_status_check;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_status_check
+    CHECK (status IN ('OPEN', 'MERGED'));
@@ -0,0 +1,3 @@
+ALTER TABLE team_settings DROP COLUMN IF EXISTS required_approvals;
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS decided_at;
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS review_decision;
@@ -0,0 +1,7 @@
+-- Решения ревьюверов и обязательные одобрения команды
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS review_decision VARCHAR(50) NOT NULL DEFAULT 'PENDING'
+    CHECK (review_decision IN ('PENDING', 'APPROVED', 'CHANGES_REQUESTED'));
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS decided_at TIMESTAMP;
+
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS required_approvals INTEGER NOT NULL DEFAULT 0
+    CHECK (required_approvals >= 0);
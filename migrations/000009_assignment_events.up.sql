@@ -0,0 +1,15 @@
+-- Неизменяемая история назначений ревьюверов
+CREATE TABLE IF NOT EXISTS pr_assignment_events (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    reviewer_id VARCHAR(255),
+    previous_reviewer_id VARCHAR(255),
+    actor_id VARCHAR(255),
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    CHECK (event_type IN ('assigned', 'unassigned', 'reassigned', 'merged', 'closed'))
+);
+
+CREATE INDEX idx_pr_assignment_events_pr ON pr_assignment_events(pull_request_id, id);
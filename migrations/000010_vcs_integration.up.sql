@@ -0,0 +1,17 @@
+-- Сопоставление логинов внешних систем контроля версий с пользователями
+CREATE TABLE IF NOT EXISTS vcs_identities (
+    provider VARCHAR(50) NOT NULL,
+    login VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, login),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- Обработанные доставки вебхуков (защита от повторной обработки)
+CREATE TABLE IF NOT EXISTS vcs_deliveries (
+    provider VARCHAR(50) NOT NULL,
+    delivery_id VARCHAR(255) NOT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, delivery_id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS webhook_jobs;
+DROP TABLE IF EXISTS outbox;
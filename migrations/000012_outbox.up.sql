@@ -0,0 +1,36 @@
+-- Доменные события, записанные в одной транзакции с изменением данных.
+-- Фоновый диспетчер публикует их и отмечает delivered_at
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL UNIQUE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX idx_outbox_pending ON outbox(id) WHERE delivered_at IS NULL;
+
+-- Задания доставки события подписчику. Создаются в той же транзакции, в которой
+-- событие публикуется из outbox, и завершаются только после ответа подписчика
+-- или исчерпания попыток, поэтому остановка сервиса не теряет события
+CREATE TABLE IF NOT EXISTS webhook_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    -- тело хранится как есть: подпись считается по точным байтам
+    body TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    -- повторная публикация события из outbox не создает второе задание
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX idx_webhook_jobs_due ON webhook_jobs(next_attempt_at) WHERE completed_at IS NULL;
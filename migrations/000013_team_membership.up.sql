@@ -0,0 +1,19 @@
+-- Пользователь может не состоять ни в одной команде (после /team/removeMember)
+ALTER TABLE users ALTER COLUMN team_name DROP NOT NULL;
+
+-- Переименование команды каскадно обновляет ссылки на нее
+ALTER TABLE users DROP CONSTRAINT users_team_name_fkey;
+ALTER TABLE users ADD CONSTRAINT users_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE;
+
+ALTER TABLE team_settings DROP CONSTRAINT team_settings_team_name_fkey;
+ALTER TABLE team_settings ADD CONSTRAINT team_settings_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE;
+
+ALTER TABLE team_fallbacks DROP CONSTRAINT team_fallbacks_team_name_fkey;
+ALTER TABLE team_fallbacks ADD CONSTRAINT team_fallbacks_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE;
+
+ALTER TABLE team_fallbacks DROP CONSTRAINT team_fallbacks_fallback_team_fkey;
+ALTER TABLE team_fallbacks ADD CONSTRAINT team_fallbacks_fallback_team_fkey
+    FOREIGN KEY (fallback_team) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE;
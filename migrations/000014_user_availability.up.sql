@@ -0,0 +1,22 @@
+-- Периоды отсутствия пользователей (отпуск, болезнь). Пока текущее время
+-- внутри периода, пользователь не выбирается ревьювером. reassigned_at
+-- отмечает, что фоновая задача уже переназначила его открытые ревью.
+-- Если переназначение не удалось, ошибка сохраняется в last_error,
+-- а следующая попытка откладывается до retry_at
+CREATE TABLE IF NOT EXISTS user_availability (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    reassigned_at TIMESTAMPTZ,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    retry_at TIMESTAMPTZ,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_user_availability_user ON user_availability(user_id, ends_at);
+CREATE INDEX idx_user_availability_pending ON user_availability(starts_at) WHERE reassigned_at IS NULL;
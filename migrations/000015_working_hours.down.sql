@@ -0,0 +1,6 @@
+ALTER TABLE team_settings DROP COLUMN IF EXISTS prefer_working_hours;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_working_hours_check;
+ALTER TABLE users DROP COLUMN IF EXISTS work_end;
+ALTER TABLE users DROP COLUMN IF EXISTS work_start;
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
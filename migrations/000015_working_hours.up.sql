@@ -0,0 +1,10 @@
+-- Часовой пояс и рабочие часы пользователя (локальное время, HH:MM).
+-- Если work_end раньше work_start, рабочий день переходит через полночь
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS work_start TIME;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS work_end TIME;
+ALTER TABLE users ADD CONSTRAINT users_working_hours_check
+    CHECK ((work_start IS NULL) = (work_end IS NULL));
+
+-- Предпочитать кандидатов, у которых сейчас рабочее время
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS prefer_working_hours BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,19 @@
+-- Правила владения кодом в стиле CODEOWNERS: glob-шаблон пути и владельцы.
+-- Для каждого пути действует последнее подходящее правило (по id)
+CREATE TABLE IF NOT EXISTS ownership_rules (
+    id BIGSERIAL PRIMARY KEY,
+    pattern TEXT NOT NULL,
+    owner_users TEXT[] NOT NULL DEFAULT '{}',
+    owner_teams TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Пути, измененные в PR. Нужны и при назначении ревьюверов позже
+-- (выход из черновика, переназначение)
+CREATE TABLE IF NOT EXISTS pr_changed_files (
+    pull_request_id VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    PRIMARY KEY (pull_request_id, path),
+    FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+-- Навыки пользователей и теги PR. Ревьюверы выбираются в первую очередь
+-- по числу навыков, совпавших с тегами PR
+CREATE TABLE IF NOT EXISTS user_skills (
+    user_id VARCHAR(255) NOT NULL,
+    tag VARCHAR(32) NOT NULL,
+    PRIMARY KEY (user_id, tag),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS pr_tags (
+    pull_request_id VARCHAR(255) NOT NULL,
+    tag VARCHAR(32) NOT NULL,
+    PRIMARY KEY (pull_request_id, tag),
+    FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+ALTER TABLE team_settings DROP CONSTRAINT IF EXISTS team_settings_seniority_check;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS required_seniority_count;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS required_seniority;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_seniority_check;
+ALTER TABLE users DROP COLUMN IF EXISTS seniority;
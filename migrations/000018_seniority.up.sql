@@ -0,0 +1,10 @@
+-- Уровень пользователя и требование команды: не меньше required_seniority_count
+-- ревьюверов уровня required_seniority или выше
+ALTER TABLE users ADD COLUMN IF NOT EXISTS seniority VARCHAR(16);
+ALTER TABLE users ADD CONSTRAINT users_seniority_check
+    CHECK (seniority IN ('JUNIOR', 'MIDDLE', 'SENIOR'));
+
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS required_seniority VARCHAR(16);
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS required_seniority_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE team_settings ADD CONSTRAINT team_settings_seniority_check
+    CHECK (required_seniority_count = 0 OR required_seniority IN ('JUNIOR', 'MIDDLE', 'SENIOR'));
@@ -0,0 +1,17 @@
+-- Правила назначения ревьюверов:
+--   EXCLUDE - user_id никогда не ревьюит PR автора target_id (конфликт интересов)
+--   PAIR    - user_id назначается ревьювером только вместе с наставником target_id
+CREATE TABLE IF NOT EXISTS reviewer_rules (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(16) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (target_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    CHECK (kind IN ('EXCLUDE', 'PAIR')),
+    CHECK (user_id <> target_id),
+    UNIQUE (kind, user_id, target_id)
+);